@@ -2,13 +2,18 @@ package substrate
 
 import (
 	"bytes"
-	"encoding/hex"
-	"log"
-	"os/exec"
+	"context"
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/blake2b"
 )
 
 const (
@@ -16,17 +21,52 @@ const (
 	AlicePubKey = "0xd43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d"
 )
 
-type MortalEra struct {
-	Period uint64
-	Phase uint64
-}
+// Error codes of the transaction pool returned by author_submitExtrinsic
+const (
+	ErrCodeInvalidTransaction = 1010
+	ErrCodeUnknownTransaction = 1011
+	ErrCodeTemporarilyBanned  = 1012
+	ErrCodeAlreadyImported    = 1013
+	ErrCodeTooLowPriority     = 1014
+	ErrCodeCycleDetected      = 1015
+	ErrCodeImmediatelyDropped = 1016
+)
+
+// The version byte of an extrinsic is the format version with the signed bit
+const (
+	// ExtrinsicBitSigned is set in the version byte of a signed extrinsic
+	ExtrinsicBitSigned = 0x80
+	// ExtrinsicUnmaskVersion masks the format version of the version byte
+	ExtrinsicUnmaskVersion = 0x7f
+
+	// ExtrinsicVersion1 to ExtrinsicVersion3 sign with a 64 bytes signature
+	ExtrinsicVersion1 = 1
+	ExtrinsicVersion2 = 2
+	ExtrinsicVersion3 = 3
+	// ExtrinsicVersion4 signs with a MultiSignature, the signature is prefixed by its type
+	ExtrinsicVersion4 = 4
+)
+
+// The types of a MultiSignature
+const (
+	MultiSignatureEd25519 = 0
+	MultiSignatureSr25519 = 1
+	MultiSignatureEcdsa   = 2
+)
 
 type ExtrinsicSignature struct {
-	SignatureOptional uint8
 	Signer Address
+	// SignerIndex is the account index of the signer when it is addressed by its index instead of Signer
+	SignerIndex *AccountIndex
+	// SignatureType is the type of the MultiSignature, only encoded from ExtrinsicVersion4
+	SignatureType uint8
 	Signature Signature
+	// EcdsaSignature is the signature of the MultiSignatureEcdsa type instead of Signature, with the recovery ID
+	EcdsaSignature [65]byte
 	Nonce uint64
-	Era uint8 // era enum
+	Era ExtrinsicEra
+	// Extra is the encoded extra data of the signed extensions, replacing the nonce and the era when set
+	Extra []byte
 }
 
 
@@ -34,38 +74,78 @@ func NewExtrinsicSignature(signature Signature, Nonce uint64) ExtrinsicSignature
 	return ExtrinsicSignature{Signature: signature, Nonce: Nonce}
 }
 
-func (e *ExtrinsicSignature) ParityDecode(decoder scalecodec.Decoder) {
-	// length of the encoded signature struct
-	//l := decoder.DecodeUintCompact()
-	//fmt.Println(l)
-	decoder.Decode(&e.SignatureOptional) // implement decodeExtrinsicSignature logic to derive if the request is signed
+// Account is the sender of the extrinsics of an Author
+type Account struct {
+	// PublicKey is the account ID of the sender
+	PublicKey []byte
+	// Signer signs with the key of the account
+	Signer signature.Signer
+	// SignatureType is the MultiSignature type of the signatures, eg: MultiSignatureSr25519
+	SignatureType uint8
+}
+
+// NewKeyringAccount is the account of the keyring pair, signing in process
+func NewKeyringAccount(pair signature.KeyringPair) Account {
+	st := uint8(MultiSignatureSr25519)
+	if pair.Type() == signature.ED25519 {
+		st = MultiSignatureEd25519
+	}
+	return Account{PublicKey: pair.PublicKey(), Signer: signature.NewKeyringSigner(pair), SignatureType: st}
+}
 
-	b := decoder.ReadOneByte()
-	// need to add other address representations from Address.decodeAddress
-	if b == 255 {
-		e.Signer = Address{}
-		decoder.Decode(&e.Signer)
+// decodeSigner reads the signer and the signature of an extrinsic of the format version
+func (e *ExtrinsicSignature) decodeSigner(decoder scalecodec.Decoder, version uint8) {
+	switch a := decodeAddress(decoder).(type) {
+	case Address:
+		e.Signer = a
+	case AccountIndex:
+		e.SignerIndex = &a
 	}
 
 	e.Signature = Signature{}
-	decoder.Decode(&e.Signature)
-	e.Nonce = decoder.DecodeUintCompact()
-	// assuming immortal for now TODO
-	decoder.Decode(&e.Era)
+	if version < ExtrinsicVersion4 {
+		decoder.Decode(&e.Signature)
+		return
+	}
 
+	e.SignatureType = decoder.ReadOneByte()
+	switch e.SignatureType {
+	case MultiSignatureEd25519, MultiSignatureSr25519:
+		decoder.Decode(&e.Signature)
+	case MultiSignatureEcdsa:
+		decoder.Read(e.EcdsaSignature[:])
+	default:
+		panic(fmt.Sprintf("unsupported signature type %d", e.SignatureType))
+	}
 }
 
-func (e ExtrinsicSignature) ParityEncode(encoder scalecodec.Encoder) {
-	// always signed
-	e.SignatureOptional = 129
-	// Alice
-	s, _ := hexutil.Decode(AlicePubKey)
-	e.Signer = *NewAddress(s)
-	e.Era = 0
+// decode reads the signature of an extrinsic of the format version followed by the nonce and the era
+func (e *ExtrinsicSignature) decode(decoder scalecodec.Decoder, version uint8) {
+	e.decodeSigner(decoder, version)
+	e.Nonce = decoder.DecodeUintCompact()
+	decoder.Decode(&e.Era)
+}
 
-	encoder.Encode(e.SignatureOptional)
-	encoder.Encode(&e.Signer)
-	encoder.Encode(&e.Signature)
+// encode writes the signature of an extrinsic of the format version followed by the extra data of the signed
+// extensions, or else the nonce and the era
+func (e ExtrinsicSignature) encode(encoder scalecodec.Encoder, version uint8) {
+	if e.SignerIndex != nil {
+		encodeAccountIndex(encoder, *e.SignerIndex)
+	} else {
+		encoder.Encode(&e.Signer)
+	}
+	if version >= ExtrinsicVersion4 {
+		encoder.PushByte(e.SignatureType)
+	}
+	if version >= ExtrinsicVersion4 && e.SignatureType == MultiSignatureEcdsa {
+		encoder.Write(e.EcdsaSignature[:])
+	} else {
+		encoder.Encode(&e.Signature)
+	}
+	if e.Extra != nil {
+		encoder.Write(e.Extra)
+		return
+	}
 	encoder.EncodeUintCompact(e.Nonce)
 	encoder.Encode(e.Era)
 }
@@ -73,8 +153,8 @@ func (e ExtrinsicSignature) ParityEncode(encoder scalecodec.Encoder) {
 type SignaturePayload struct {
 	Nonce uint64
 	Method Method
-	Era uint8 // era enum
-	//ImmortalEra []byte
+	Era ExtrinsicEra
+	// PriorBlock is the genesis hash for an immortal era or the hash of the birth block for a mortal era
 	PriorBlock [32]byte
 }
 
@@ -82,7 +162,6 @@ func (e SignaturePayload) ParityEncode(encoder scalecodec.Encoder) {
 	encoder.EncodeUintCompact(e.Nonce)
 	encoder.Encode(e.Method)
 	encoder.Encode(e.Era)
-	// encoder.Encode(e.ImmortalEra) // always immortal
 	encoder.Write(e.PriorBlock[:])
 }
 
@@ -102,73 +181,195 @@ func NewMethod(name string, a Args, metadata MetadataVersioned) Method {
 	return Method{CallIndex: metadata.Metadata.MethodIndex(name), Args:a}
 }
 
+// ParityDecode decodes the arguments in to the preset Args, use TypeRegistry.DecodeMethod to decode the arguments
+// using the metadata
 func (e *Method) ParityDecode(decoder scalecodec.Decoder) {
 	decoder.Decode(&e.CallIndex)
-	//e.Args = &AnchorParams{}
 	decoder.Decode(e.Args)
 }
 
 func (m Method) ParityEncode(encoder scalecodec.Encoder) {
 	encoder.Encode(&m.CallIndex)
-	encoder.Encode(m.Args)
+	// encoded directly so that the slice arguments, eg: RawArgs, are not length prefixed
+	m.Args.ParityEncode(encoder)
 }
 
 type Extrinsic struct {
-	subKeyCMD string
-	subKeySign string
+	account Account
+	// Version is the version byte, the format version with ExtrinsicBitSigned for a signed extrinsic
+	Version uint8
 	Nonce uint64
-	// BestKnownBlock genesis block
+	// BestKnownBlock is the genesis hash for an immortal era or the hash of the birth block for a mortal era
 	BestKnownBlock []byte
+	// Era is the validity period of the extrinsic, immortal by default
+	Era            ExtrinsicEra
+	// Tip, SpecVersion, TransactionVersion and GenesisHash are only encoded by the signed extensions
+	Tip                uint64
+	SpecVersion        uint32
+	TransactionVersion uint32
+	GenesisHash        []byte
+	// Extensions are the signed extensions of the runtime in the order of the metadata, the nonce and the era are
+	// encoded without extensions when empty
+	Extensions []SignedExtension
 	Signature      ExtrinsicSignature
 	Method         Method
+	// encoded extrinsic, set when the extrinsic is decoded
+	encoded []byte
 }
 
-func NewExtrinsic(subKeyCMD string, subKeySign string, accountNonce uint64, bestKnownBlock []byte, method Method) *Extrinsic {
-	return &Extrinsic{subKeyCMD: subKeyCMD, subKeySign: subKeySign, Nonce:accountNonce, BestKnownBlock: bestKnownBlock, Method:method}
+// NewExtrinsic creates an extrinsic sent by the account, signed with ExtrinsicVersion1
+func NewExtrinsic(account Account, accountNonce uint64, bestKnownBlock []byte, method Method) *Extrinsic {
+	return &Extrinsic{account: account, Version: ExtrinsicBitSigned | ExtrinsicVersion1, Nonce:accountNonce,
+		BestKnownBlock: bestKnownBlock, Method:method}
 }
 
-func (e *Extrinsic) ParityDecode(decoder scalecodec.Decoder) {
-	// length (not used)
-	decoder.DecodeUintCompact()
+// NewUnsignedExtrinsic creates an unsigned extrinsic of the format version, eg: of a call validated by the runtime
+func NewUnsignedExtrinsic(version uint8, method Method) *Extrinsic {
+	return &Extrinsic{Version: version & ExtrinsicUnmaskVersion, Method: method}
+}
+
+// IsSigned returns true if the extrinsic is signed
+func (e *Extrinsic) IsSigned() bool {
+	return e.Version&ExtrinsicBitSigned != 0
+}
+
+// FormatVersion is the version of the format of the extrinsic, eg: ExtrinsicVersion4
+func (e *Extrinsic) FormatVersion() uint8 {
+	return e.Version & ExtrinsicUnmaskVersion
+}
+
+// Hash of the extrinsic as returned by author_submitExtrinsic, only available for decoded extrinsics
+func (e *Extrinsic) Hash() Hash {
+	if e.encoded == nil {
+		return nil
+	}
 
+	h := blake2b.Sum256(e.encoded)
+	return h[:]
+}
+
+// ParityDecode decodes an extrinsic of any version without signed extensions in to the preset Method arguments, use
+// TypeRegistry.DecodeExtrinsic to decode the signed extensions and the arguments using the metadata
+func (e *Extrinsic) ParityDecode(decoder scalecodec.Decoder) {
+	l := decoder.DecodeUintCompact()
+	b := make([]byte, l)
+	decoder.Read(b)
+
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).EncodeUintCompact(l)
+	bb.Write(b)
+	e.encoded = bb.Bytes()
+
+	// the extrinsic is decoded from its bytes so that it can't read past its length
+	d := scalecodec.NewDecoder(bytes.NewReader(b))
+	e.Version = d.ReadOneByte()
 	e.Signature = ExtrinsicSignature{}
-	decoder.Decode(&e.Signature)
-	decoder.Decode(&e.Method)
+	if e.IsSigned() {
+		e.Signature.decode(*d, e.FormatVersion())
+		e.Nonce = e.Signature.Nonce
+		e.Era = e.Signature.Era
+	}
+	// decoded in place to keep the preset arguments
+	e.Method.ParityDecode(*d)
 }
 
-func (e Extrinsic) ParityEncode(encoder scalecodec.Encoder) {
+// params are the values of the signed extensions
+func (e *Extrinsic) params() ExtensionParams {
+	return ExtensionParams{
+		Nonce:              e.Nonce,
+		Era:                e.Era,
+		Tip:                e.Tip,
+		SpecVersion:        e.SpecVersion,
+		TransactionVersion: e.TransactionVersion,
+		GenesisHash:        e.GenesisHash,
+		BlockHash:          e.BestKnownBlock,
+	}
+}
+
+// extra encodes the extra data of the signed extensions
+func (e *Extrinsic) extra() []byte {
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	params := e.params()
+	for _, ext := range e.Extensions {
+		ext.Extra(*enc, params)
+	}
+	return bb.Bytes()
+}
+
+// maxUnhashedPayload is the largest payload signed as is, the larger payloads are signed by their blake2b-256 hash
+const maxUnhashedPayload = 256
+
+// SignaturePayload returns the payload signed by the sender, the encoded payload or its blake2b-256 hash if the
+// encoding is larger than 256 bytes
+func (e *Extrinsic) SignaturePayload() []byte {
+	b := e.encodePayload()
+	if len(b) > maxUnhashedPayload {
+		h := blake2b.Sum256(b)
+		return h[:]
+	}
+	return b
+}
+
+// encodePayload encodes the call followed by the extra and the additional signed data of the signed extensions
+func (e *Extrinsic) encodePayload() []byte {
 	b := make([]byte, 0, 1000)
 	bb := bytes.NewBuffer(b)
 	tempEnc := scalecodec.NewEncoder(bb)
+	if len(e.Extensions) > 0 {
+		tempEnc.Encode(e.Method)
+		bb.Write(e.extra())
+		params := e.params()
+		for _, ext := range e.Extensions {
+			ext.AdditionalSigned(*tempEnc, params)
+		}
+		return bb.Bytes()
+	}
 
 	sigPay := SignaturePayload{
 		Nonce: e.Nonce,
 		Method: e.Method,
-		// Immortal
-		Era: 0,
+		Era: e.Era,
 	}
 	copy(sigPay.PriorBlock[:], e.BestKnownBlock)
 	tempEnc.Encode(sigPay)
-	bbb := bb.Bytes()
-	encoded := hex.EncodeToString(bbb)
+	return bb.Bytes()
+}
 
+// Sign signs the payload with the signer of the account and sets the signature
+func (e *Extrinsic) Sign(ctx context.Context, payload []byte) error {
+	if e.account.Signer == nil {
+		return errors.New("no signer to sign the extrinsic")
+	}
 
-	// use "subKey" command for signature
-	out, err := exec.Command(e.subKeyCMD, e.subKeySign, encoded, Alice).Output()
-	// fmt.Println(SubKeyCmd, SubKeySign, encoded, Alice)
+	vs, err := e.account.Signer.Sign(ctx, payload)
 	if err != nil {
-		log.Fatal(err.Error())
+		return err
 	}
 
-	v := string(out)
-	vs, err := hex.DecodeString(v)
-
 	e.Signature = NewExtrinsicSignature(*NewSignature(vs), e.Nonce)
+	e.Signature.Signer = *NewAddress(e.account.PublicKey)
+	e.Signature.SignatureType = e.account.SignatureType
+	if e.account.SignatureType == MultiSignatureEcdsa {
+		e.Signature.Signature = Signature{}
+		copy(e.Signature.EcdsaSignature[:], vs)
+	}
+	e.Signature.Era = e.Era
+	if len(e.Extensions) > 0 {
+		e.Signature.Extra = e.extra()
+	}
+	return nil
+}
 
-	b = make([]byte, 0, 1000)
-	bb = bytes.NewBuffer(b)
-	tempEnc = scalecodec.NewEncoder(bb)
-	tempEnc.Encode(&e.Signature)
+// ParityEncode encodes the extrinsic with the version byte, a signed extrinsic must be signed with Sign first
+func (e Extrinsic) ParityEncode(encoder scalecodec.Encoder) {
+	b := make([]byte, 0, 1000)
+	bb := bytes.NewBuffer(b)
+	tempEnc := scalecodec.NewEncoder(bb)
+	tempEnc.PushByte(e.Version)
+	if e.IsSigned() {
+		e.Signature.encode(*tempEnc, e.FormatVersion())
+	}
 	tempEnc.Encode(&e.Method)
 
 	// encode with length prefix
@@ -181,39 +382,271 @@ type Author struct {
 	client Client
 	meta MetadataVersioned
 
-	// mu is an exclusive lock to manage the nonce
+	// mu guards the settings of the extrinsics
 	mu sync.RWMutex
 
-	subKeyCMD string
-	subKeySign string
+	// account sends the extrinsics
+	account Account
+
+	// nonces allocates the nonces of the account
+	nonces *NonceManager
+	// genesisHash is the hash of the genesis block, the checkpoint of the immortal extrinsics
+	genesisHash []byte
+	// finalized is the reference block of the mortal eras, the best block is used until the Author is refreshed
+	finalized *Header
 
-	// TODO obtain these using RPCs
-	accountNonce uint64
-	bestKnownBlock []byte
+	// mortality is the period of the era of the extrinsics, 0 for immortal extrinsics
+	mortality uint64
 
+	// registry has the signed extensions of the metadata
+	registry           *TypeRegistry
+	tip                uint64
+	specVersion        uint32
+	transactionVersion uint32
+	// version is the format version of the extrinsics
+	version uint8
+
+	tracer trace.Tracer
+}
+
+// NewAuthorRPC submits the extrinsics of the account to the chain of the genesis hash, starting with the given nonce.
+// Use NewAuthor to discover the chain from the node.
+func NewAuthorRPC(startNonce uint64, genesisHash []byte, account Account, meta MetadataVersioned, client Client) *Author {
+	a := &Author{client: client, meta: meta, account: account, genesisHash: genesisHash,
+		registry: NewTypeRegistry(), version: ExtrinsicVersion1, tracer: defaultTracer()}
+	a.nonces = NewNonceManager(client, account.PublicKey, &meta)
+	a.nonces.Set(startNonce)
+	return a
 }
 
-func NewAuthorRPC(startNonce uint64, bestKnownBlock []byte, subKeyCMD , SubKeySign string, meta MetadataVersioned, client Client) *Author {
-	return &Author{ client, meta, sync.RWMutex{}, subKeyCMD, SubKeySign, startNonce, bestKnownBlock}
+// Nonces returns the nonce manager of the account, eg: to resync the nonce from the chain instead of the start nonce
+func (a *Author) Nonces() *NonceManager {
+	return a.nonces
 }
 
-func (a *Author) SubmitExtrinsic(method string, args Args) (string, error) {
+// submitted releases the nonce of an extrinsic rejected by the node so that it is reused. The nonce is synchronised
+// from the chain on the next submission if the pool rejected the nonce, if the extrinsic is invalid as the reason may
+// be the nonce, or if the extrinsic may have reached the pool, eg: the connection failed.
+func (a *Author) submitted(nonce uint64, err error) {
+	var rerr rpc.Error
+	switch {
+	case err == nil:
+	case IsNonceError(err):
+		a.nonces.invalidate()
+	case errors.As(err, &rerr) && rerr.ErrorCode() != ErrCodeInvalidTransaction:
+		a.nonces.Release(nonce)
+	default:
+		a.nonces.invalidate()
+	}
+}
+
+// SetExtrinsicVersion sets the format version of the extrinsics, ExtrinsicVersion1 by default. Refresh sets the
+// version of the metadata from v11. The signatures of ExtrinsicVersion4 are typed by the SignatureType of the account.
+func (a *Author) SetExtrinsicVersion(version uint8) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.version = version & ExtrinsicUnmaskVersion
+}
+
+// TypeRegistry returns the registry of the signed extensions, eg: to register the custom extensions of the runtime
+func (a *Author) TypeRegistry() *TypeRegistry {
+	return a.registry
+}
+
+// SetTip sets the tip paid by the extrinsics to increase their priority, encoded by the ChargeTransactionPayment
+// signed extension
+func (a *Author) SetTip(tip uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.tip = tip
+}
+
+// SetRuntimeVersion sets the versions of the runtime encoded by the CheckSpecVersion and CheckTxVersion signed
+// extensions
+func (a *Author) SetRuntimeVersion(specVersion, transactionVersion uint32) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.specVersion = specVersion
+	a.transactionVersion = transactionVersion
+}
+
+// SetMortality makes the extrinsics valid for the period of blocks from the reference block, the finalized block
+// once the Author is refreshed or else the best block. The period is rounded to a
+// power of two between 4 and 65536. The extrinsics are immortal if the period is 0, the default.
+func (a *Author) SetMortality(period uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.mortality = period
+}
+
+// SetTracerProvider sets the provider of the extrinsic lifecycle spans, the global provider is used by default
+func (a *Author) SetTracerProvider(tp trace.TracerProvider) {
 	a.mu.Lock()
-	e :=  NewExtrinsic(a.subKeyCMD, a.subKeySign, a.accountNonce, a.bestKnownBlock, NewMethod(method, args, a.meta))
-	a.accountNonce++
-	a.mu.Unlock()
+	defer a.mu.Unlock()
+	a.tracer = tp.Tracer(tracerName)
+}
+
+// startSpan starts a span with the tracer of the Author
+func (a *Author) startSpan(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	a.mu.RLock()
+	tracer := a.tracer
+	a.mu.RUnlock()
+	return tracer.Start(ctx, name, opts...)
+}
+
+func (a *Author) SubmitExtrinsic(ctx context.Context, method string, args Args) (res string, err error) {
+	ctx, span := a.startSpan(ctx, "Author.SubmitExtrinsic", trace.WithAttributes(attribute.String("substrate.call", method)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	eb, nonce, err := a.encodeExtrinsic(ctx, method, args)
+	if err != nil {
+		return "", err
+	}
+
+	sctx, sspan := a.startSpan(ctx, "author_submitExtrinsic")
+	err = a.client.CallContext(sctx, &res, "author_submitExtrinsic", eb)
+	endSpan(sspan, err)
+	a.submitted(nonce, err)
+	if err != nil {
+		return "", err
+	}
+
+	span.SetAttributes(attribute.String("substrate.extrinsic_hash", res))
+	return res, nil
+}
+
+// SubmitUnsignedExtrinsic submits the call as an unsigned extrinsic, the call must be validated by the runtime
+func (a *Author) SubmitUnsignedExtrinsic(ctx context.Context, method string, args Args) (res string, err error) {
+	ctx, span := a.startSpan(ctx, "Author.SubmitUnsignedExtrinsic", trace.WithAttributes(attribute.String("substrate.call", method)))
+	defer func() {
+		endSpan(span, err)
+	}()
+
+	a.mu.RLock()
+	e := NewUnsignedExtrinsic(a.version, NewMethod(method, args, a.meta))
+	a.mu.RUnlock()
+
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e)
+	sctx, sspan := a.startSpan(ctx, "author_submitExtrinsic")
+	err = a.client.CallContext(sctx, &res, "author_submitExtrinsic", hexutil.Encode(bb.Bytes()))
+	endSpan(sspan, err)
+	if err != nil {
+		return "", err
+	}
+
+	span.SetAttributes(attribute.String("substrate.extrinsic_hash", res))
+	return res, nil
+}
+
+// encodeExtrinsic creates, signs and encodes the extrinsic with the next nonce and the tip of the Author
+func (a *Author) encodeExtrinsic(ctx context.Context, method string, args Args) (string, uint64, error) {
+	a.mu.RLock()
+	tip := a.tip
+	a.mu.RUnlock()
+	return a.encodeWith(ctx, method, args, nil, tip)
+}
+
+// encodeWith creates, signs and encodes the extrinsic with a span for every step. The next nonce is allocated when
+// the nonce is nil, eg: the nonce of a replaced extrinsic is reused, and released if the extrinsic can't be signed.
+func (a *Author) encodeWith(ctx context.Context, method string, args Args, nonce *uint64, tip uint64) (string, uint64, error) {
+	a.mu.RLock()
+	meta := a.meta
+	mortality := a.mortality
+	finalized := a.finalized
+	a.mu.RUnlock()
+
+	_, span := a.startSpan(ctx, "metadata.lookup")
+	m := NewMethod(method, args, meta)
+	span.SetAttributes(attribute.Int("substrate.call_section", int(m.CallIndex.SectionIndex)),
+		attribute.Int("substrate.call_method", int(m.CallIndex.MethodIndex)))
+	span.End()
+
+	exts, err := a.registry.signedExtensions(meta.Extrinsic.SignedExtensions)
+	if err != nil {
+		return "", 0, err
+	}
+
+	era, checkpoint, err := a.era(ctx, mortality, finalized)
+	if err != nil {
+		return "", 0, err
+	}
+
+	release := func(uint64) {}
+	if nonce == nil {
+		n, err := a.nonces.Next(ctx)
+		if err != nil {
+			return "", 0, err
+		}
+		nonce = &n
+		release = a.nonces.Release
+	}
+
+	a.mu.RLock()
+	if checkpoint == nil {
+		checkpoint = a.genesisHash
+	}
+	e :=  NewExtrinsic(a.account, *nonce, checkpoint, m)
+	e.Version = ExtrinsicBitSigned | a.version
+	e.Era = era
+	e.Tip = tip
+	e.SpecVersion = a.specVersion
+	e.TransactionVersion = a.transactionVersion
+	e.GenesisHash = a.genesisHash
+	e.Extensions = exts
+	a.mu.RUnlock()
+
+	_, span = a.startSpan(ctx, "extrinsic.encode", trace.WithAttributes(attribute.Int64("substrate.nonce", int64(e.Nonce))))
+	payload := e.SignaturePayload()
+	span.End()
+
+	sctx, span := a.startSpan(ctx, "extrinsic.sign")
+	err = e.Sign(sctx, payload)
+	endSpan(span, err)
+	if err != nil {
+		release(*nonce)
+		return "", 0, err
+	}
+
 	bb := make([]byte, 0, 1000)
 	bbb := bytes.NewBuffer(bb)
 	tempEnc := scalecodec.NewEncoder(bbb)
 	tempEnc.Encode(&e)
-	eb := hexutil.Encode(bbb.Bytes())
-	// fmt.Println(eb)
+	return hexutil.Encode(bbb.Bytes()), *nonce, nil
+}
 
-	var res string
-	err := a.client.Call(&res, "author_submitExtrinsic", eb)
-	if err != nil {
-		return "", err
+// era returns the era of the period from the reference block, or the best block if nil, and the hash of its birth
+// block, the immortal era and no hash if the period is 0
+func (a *Author) era(ctx context.Context, period uint64, reference *Header) (ExtrinsicEra, []byte, error) {
+	if period == 0 {
+		return ExtrinsicEra{}, nil, nil
 	}
 
-	return res, nil
-}
\ No newline at end of file
+	ctx, span := a.startSpan(ctx, "extrinsic.era")
+	era, checkpoint, err := mortalEra(ctx, NewChainRPC(a.client), period, reference)
+	endSpan(span, err)
+	return era, checkpoint, err
+}
+
+func mortalEra(ctx context.Context, chain *Chain, period uint64, best *Header) (ExtrinsicEra, []byte, error) {
+	var err error
+	if best == nil {
+		best, err = chain.Header(ctx, nil)
+		if err != nil {
+			return ExtrinsicEra{}, nil, err
+		}
+	}
+
+	m := NewMortalEra(period, best.Number)
+	birth := m.Birth(best.Number)
+	checkpoint := best.Hash()
+	if birth != best.Number {
+		checkpoint, err = chain.BlockHash(ctx, birth)
+		if err != nil {
+			return ExtrinsicEra{}, nil, err
+		}
+	}
+	return ExtrinsicEra{IsMortalEra: true, AsMortalEra: m}, checkpoint, nil
+}
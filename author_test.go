@@ -0,0 +1,326 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+	"golang.org/x/crypto/blake2b"
+)
+
+// assertPoolError asserts that the error is a transaction pool error with the code
+func assertPoolError(t *testing.T, code int, err error) {
+	if assert.Error(t, err) {
+		rerr, ok := err.(rpc.Error)
+		if assert.True(t, ok, "not an rpc error: %v", err) {
+			assert.Equal(t, code, rerr.ErrorCode())
+		}
+	}
+}
+
+func TestAuthor_SubmitExtrinsic_Node(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	a1, _ := testAnchorCall()
+	a2 := a1
+	a2.Proof[0] = 4
+
+	// nonce 1 waits in the pool for nonce 0
+	future := newTestAuthor(t, c)
+	future.nonces.Set(1)
+	_, err = future.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	n.ProduceBlock()
+	assert.Len(t, n.Pending(), 1)
+
+	a := newTestAuthor(t, c)
+	var alice [32]byte
+	copy(alice[:], a.account.PublicKey)
+	h, err := a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	assert.Len(t, n.Pending(), 2)
+
+	// the same extrinsic and another one with the same nonce
+	a.nonces.Set(0)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, substratetest.ErrCodeAlreadyImported, err)
+	a.nonces.Set(0)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a2)
+	assertPoolError(t, substratetest.ErrCodeTooLowPriority, err)
+
+	block := n.ProduceBlock()
+	assert.Empty(t, n.Pending())
+	assert.Equal(t, uint64(2), n.Nonce(alice))
+	extrinsics := n.Extrinsics(block)
+	if assert.Len(t, extrinsics, 2) {
+		eh := blake2b.Sum256(extrinsics[0])
+		assert.Equal(t, h, hexutil.Encode(eh[:]))
+	}
+
+	a.nonces.Set(1)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a2)
+	assertPoolError(t, substratetest.ErrCodeInvalidTransaction, err)
+}
+
+func TestAuthor_SubmitExtrinsic_SignError(t *testing.T) {
+	m := newMockClient()
+	pair, err := signature.KeyringPairFromURI(Alice, signature.SR25519)
+	assert.NoError(t, err)
+	pair.Lock()
+	a := NewAuthorRPC(0, make([]byte, 32), NewKeyringAccount(pair), *testMetadata(t), m)
+
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.Equal(t, signature.ErrLocked, err)
+	assert.Equal(t, 0, m.callCount("author_submitExtrinsic"))
+}
+
+func TestAuthor_SubmitExtrinsic_RemoteSigner(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	signer := substratetest.NewSigner()
+	server := httptest.NewServer(signature.NewSignerHandler(map[string]signature.Signer{"alice": signer}))
+	defer server.Close()
+	var audit []signature.AuditRecord
+	remote := signature.NewRemoteSigner(signature.RemoteSignerConfig{URL: server.URL, KeyID: "alice",
+		Audit: func(r signature.AuditRecord) {
+			audit = append(audit, r)
+		}})
+	a := NewAuthorRPC(0, n.BlockHash(0), Account{PublicKey: hexutil.MustDecode(AlicePubKey), Signer: remote},
+		*testMetadata(t), c)
+
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	pending := n.Pending()
+	assert.Len(t, pending, 1)
+	if assert.Len(t, signer.Payloads(), 1) && assert.Len(t, audit, 1) {
+		sig := blake2b.Sum512(signer.Payloads()[0])
+		assert.True(t, bytes.Contains(pending[0], sig[:]))
+		h := blake2b.Sum256(signer.Payloads()[0])
+		assert.Equal(t, h[:], audit[0].PayloadHash)
+		assert.Equal(t, sig[:], audit[0].Signature)
+		assert.NoError(t, audit[0].Err)
+	}
+
+	signer.SetError(errors.New("key not available"))
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.Contains(t, err.Error(), "key not available")
+	assert.Len(t, audit, 2)
+	assert.Len(t, n.Pending(), 1)
+}
+
+func TestAuthor_SubmitExtrinsic_Accounts(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var authors []*Author
+	var accounts [][32]byte
+	for _, suri := range []string{Alice, "//Bob"} {
+		pair, err := signature.KeyringPairFromURI(suri, signature.SR25519)
+		assert.NoError(t, err)
+		authors = append(authors, NewAuthorRPC(0, n.BlockHash(0), NewKeyringAccount(pair), *testMetadata(t), c))
+		var account [32]byte
+		copy(account[:], pair.PublicKey())
+		accounts = append(accounts, account)
+	}
+
+	a1, _ := testAnchorCall()
+	for i := 0; i < 2; i++ {
+		for _, a := range authors {
+			_, err := a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+			assert.NoError(t, err)
+		}
+	}
+
+	block := n.ProduceBlock()
+	for _, account := range accounts {
+		assert.Equal(t, uint64(2), n.Nonce(account))
+	}
+
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(context.Background(), block, testMetadata(t))
+	assert.NoError(t, err)
+	signers := make(map[[32]byte]int)
+	for _, e := range b.Block.Extrinsics {
+		signers[e.Signature.Signer.PubKey]++
+	}
+	assert.Equal(t, map[[32]byte]int{accounts[0]: 2, accounts[1]: 2}, signers)
+}
+
+func TestAuthor_SubmitExtrinsic_Mortal(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	for i := 0; i < 5; i++ {
+		n.ProduceBlock()
+	}
+
+	a := newTestAuthor(t, c)
+	a.SetMortality(64)
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+
+	block := n.ProduceBlock()
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(context.Background(), block, testMetadata(t))
+	assert.NoError(t, err)
+	assert.Len(t, b.Block.Extrinsics, 1)
+	e := b.Block.Extrinsics[0]
+	era := ExtrinsicEra{IsMortalEra: true, AsMortalEra: MortalEra{Period: 64, Phase: 5}}
+	assert.Equal(t, era, e.Signature.Era)
+	assert.Equal(t, uint64(5), era.Birth(5))
+
+	// the payload is signed with the hash of the birth block
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	m := NewMethod("kerplunk.commit", a1, *testMetadata(t))
+	payload := NewExtrinsic(NewKeyringAccount(pair), 0, n.BlockHash(5), m)
+	payload.Era = era
+	assert.True(t, pair.Verify(payload.SignaturePayload(), e.Signature.Signature.Hash[:]))
+}
+
+func TestAuthor_SubmitUnsignedExtrinsic(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	a := newTestAuthor(t, c)
+	a.SetExtrinsicVersion(ExtrinsicVersion4)
+	a1, call := testAnchorCall()
+	res, err := a.SubmitUnsignedExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+
+	pending := n.Pending()
+	assert.Len(t, pending, 1)
+	var expected bytes.Buffer
+	scalecodec.NewEncoder(&expected).EncodeUintCompact(uint64(len(call) + 1))
+	expected.WriteByte(ExtrinsicVersion4)
+	expected.Write(call)
+	assert.Equal(t, expected.Bytes(), pending[0])
+	h := blake2b.Sum256(pending[0])
+	assert.Equal(t, hexutil.Encode(h[:]), res)
+}
+
+func TestAuthor_SubmitExtrinsic_Version4(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.SR25519)
+	assert.NoError(t, err)
+	a := NewAuthorRPC(0, n.BlockHash(0), NewKeyringAccount(pair), *testMetadata(t), c)
+	a.SetExtrinsicVersion(ExtrinsicVersion4)
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+
+	block := n.ProduceBlock()
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(context.Background(), block, testMetadata(t))
+	assert.NoError(t, err)
+	e := b.Block.Extrinsics[0]
+	assert.Equal(t, uint8(ExtrinsicBitSigned|ExtrinsicVersion4), e.Version)
+	assert.Equal(t, uint8(MultiSignatureSr25519), e.Signature.SignatureType)
+	e.BestKnownBlock = n.BlockHash(0)
+	assert.True(t, pair.Verify(e.SignaturePayload(), e.Signature.Signature.Hash[:]))
+}
+
+func TestExtrinsic_SignaturePayload_Hashed(t *testing.T) {
+	meta := testMetadata(t)
+	// nonce, call index, arguments, era and block hash
+	e := NewExtrinsic(Account{}, 0, make([]byte, 32), NewMethod("kerplunk.commit", RawArgs(bytes.Repeat([]byte{1}, 220)), *meta))
+	payload := e.SignaturePayload()
+	assert.Len(t, payload, 256)
+	assert.Equal(t, []byte{0, 5, 0, 1}, payload[:4])
+
+	e.Method.Args = RawArgs(bytes.Repeat([]byte{1}, 221))
+	assert.Equal(t, "0xef99f2946516439eb2c8588809daf98bd94696a3f49f3f27e6140cc7d03ed23d", hexutil.Encode(e.SignaturePayload()))
+}
+
+// setCodeSignature is the ed25519 signature of //Alice over the blake2b-256 hash of the consensus.set_code payload
+// of TestExtrinsic_SignaturePayload_SetCode. It was not captured from a node: it was computed from the //Alice dev seed
+// 0xabf8e5bdbe30c65656c0a3cbd181ff8a56294a69dfedd27982aace4a76909115 with an RFC 8032 implementation independent of
+// this package, the hash with the reference blake2b. The ed25519 signatures are deterministic, so
+// `subkey sign --scheme ed25519 --suri //Alice --hex` of the hash gives the same signature.
+const setCodeSignature = "0xcaedbd59a401b0fea462cc5d0b6378da9a6551a51cce9b194f66d044dc15ad6adb90775bc7f22fd4f28668c96508c3538378413227ce402e701a37fff60f0608"
+
+func TestExtrinsic_SignaturePayload_SetCode(t *testing.T) {
+	meta := testMetadata(t)
+	// a 300 bytes runtime code, the payload is nonce, call index, code, era and genesis hash: 338 bytes
+	code := append([]byte("\x00asm\x01\x00\x00\x00"), make([]byte, 292)...)
+	var args bytes.Buffer
+	scalecodec.NewEncoder(&args).Encode(code)
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x88dc3417d5058ec4b4503e0c12ea1a0a89be200fe98922423d4334014fa6b0ee", hexutil.Encode(pair.PublicKey()))
+
+	genesis := bytes.Repeat([]byte{1}, 32)
+	e := NewExtrinsic(NewKeyringAccount(pair), 0, genesis, NewMethod("consensus.set_code", RawArgs(args.Bytes()), *meta))
+	assert.Len(t, e.encodePayload(), 338)
+	payload := e.SignaturePayload()
+	assert.Equal(t, "0xcb69253a22b1156e9d0b3221de17703bb7c4172b0088e6dd1f95bda9ddf7bfe3", hexutil.Encode(payload))
+
+	assert.NoError(t, e.Sign(context.Background(), payload))
+	assert.Equal(t, setCodeSignature, hexutil.Encode(e.Signature.Signature.Hash[:]))
+}
+
+func TestAuthor_SubmitExtrinsic_LargePayload(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.SR25519)
+	assert.NoError(t, err)
+	signer := substratetest.NewSigner()
+	a := NewAuthorRPC(0, n.BlockHash(0), Account{PublicKey: pair.PublicKey(), Signer: signer}, *testMetadata(t), c)
+	args := RawArgs(bytes.Repeat([]byte{1}, 1000))
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", args)
+	assert.NoError(t, err)
+
+	// the signer only sees the hash of the payload
+	payloads := signer.Payloads()
+	assert.Len(t, payloads, 1)
+	e := NewExtrinsic(Account{}, 0, n.BlockHash(0), NewMethod("kerplunk.commit", args, *testMetadata(t)))
+	h := blake2b.Sum256(e.encodePayload())
+	assert.Equal(t, h[:], payloads[0])
+}
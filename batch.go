@@ -0,0 +1,132 @@
+package substrate
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/vimukthi-git/go-substrate/signature"
+)
+
+// Batch sends requests of different methods in a single batch and decodes their typed results, eg: the data needed
+// to author extrinsics.
+//
+//	var meta MetadataVersioned
+//	var version RuntimeVersion
+//	var genesis Hash
+//	var nonce uint64
+//	b := NewBatch(client)
+//	b.Metadata(nil, &meta)
+//	b.RuntimeVersion(nil, &version)
+//	b.BlockHash(0, &genesis)
+//	b.AccountNextIndex(account, &nonce)
+//	err := b.Send(ctx)
+type Batch struct {
+	client Client
+	elems  []rpc.BatchElem
+	// decoders set the typed results from the raw results of the elements
+	decoders []func() error
+	err      error
+}
+
+// NewBatch returns an empty batch of the client
+func NewBatch(client Client) *Batch {
+	return &Batch{client: client}
+}
+
+func (b *Batch) add(result interface{}, decode func() error, method string, args ...interface{}) {
+	b.elems = append(b.elems, rpc.BatchElem{Method: method, Args: args, Result: result})
+	b.decoders = append(b.decoders, decode)
+}
+
+// Metadata adds state_getMetadata of the block, the best block when the hash is empty
+func (b *Batch) Metadata(blockHash Hash, meta *MetadataVersioned) {
+	var res string
+	b.add(&res, func() error {
+		m, err := decodeMetadata(res)
+		if err != nil {
+			return err
+		}
+		*meta = *m
+		return nil
+	}, "state_getMetadata", hashArgs(blockHash)...)
+}
+
+// RuntimeVersion adds state_getRuntimeVersion of the block, the best block when the hash is empty
+func (b *Batch) RuntimeVersion(blockHash Hash, version *RuntimeVersion) {
+	b.add(version, nil, "state_getRuntimeVersion", hashArgs(blockHash)...)
+}
+
+// BlockHash adds chain_getBlockHash of the block number, eg: 0 for the genesis hash
+func (b *Batch) BlockHash(number uint64, hash *Hash) {
+	b.add(hash, nil, "chain_getBlockHash", number)
+}
+
+// FinalizedHead adds chain_getFinalizedHead
+func (b *Batch) FinalizedHead(hash *Hash) {
+	b.add(hash, nil, "chain_getFinalizedHead")
+}
+
+// AccountNextIndex adds system_accountNextIndex of the account, the nonce following the ready extrinsics of the pool
+func (b *Batch) AccountNextIndex(account []byte, nonce *uint64) {
+	addr, err := signature.SS58Address(account)
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+		return
+	}
+
+	b.add(nonce, nil, "system_accountNextIndex", addr)
+}
+
+// Storage adds state_getStorage of the key, the value is nil if there is no value stored under the key
+func (b *Batch) Storage(key []byte, blockHash Hash, value *[]byte) {
+	var res *string
+	b.add(&res, func() error {
+		if res == nil {
+			*value = nil
+			return nil
+		}
+
+		v, err := hexutil.Decode(*res)
+		if err != nil {
+			return err
+		}
+		*value = v
+		return nil
+	}, "state_getStorage", append([]interface{}{hexutil.Encode(key)}, hashArgs(blockHash)...)...)
+}
+
+// Send sends the requests in a single batch and sets their results, the first failed request fails the batch
+func (b *Batch) Send(ctx context.Context) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	if len(b.elems) == 0 {
+		return nil
+	}
+
+	err := b.client.BatchCallContext(ctx, b.elems)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range b.elems {
+		if e.Error != nil {
+			return fmt.Errorf("%s failed: %w", e.Method, e.Error)
+		}
+
+		if b.decoders[i] == nil {
+			continue
+		}
+
+		if err := b.decoders[i](); err != nil {
+			return fmt.Errorf("%s failed: %v", e.Method, err)
+		}
+	}
+
+	return nil
+}
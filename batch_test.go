@@ -0,0 +1,56 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestBatch_Send(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	var alice [32]byte
+	copy(alice[:], pair.PublicKey())
+	n.SetNonce(alice, 7)
+	n.SetRuntimeVersion(substratetest.RuntimeVersion{SpecName: "node", SpecVersion: 3, TransactionVersion: 2})
+	n.SetStorage([]byte{1}, []byte{2})
+	n.ProduceBlock()
+
+	var meta MetadataVersioned
+	var version RuntimeVersion
+	var genesis, finalized Hash
+	var nonce uint64
+	var value, missing []byte
+	b := NewBatch(c)
+	b.Metadata(nil, &meta)
+	b.RuntimeVersion(nil, &version)
+	b.BlockHash(0, &genesis)
+	b.FinalizedHead(&finalized)
+	b.AccountNextIndex(pair.PublicKey(), &nonce)
+	b.Storage([]byte{1}, nil, &value)
+	b.Storage([]byte{2}, nil, &missing)
+	assert.NoError(t, b.Send(context.Background()))
+	assert.Equal(t, testMetadata(t).Metadata.MethodIndex("kerplunk.commit"), meta.Metadata.MethodIndex("kerplunk.commit"))
+	assert.Equal(t, uint32(3), version.SpecVersion)
+	assert.Equal(t, uint32(2), version.TransactionVersion)
+	assert.Equal(t, Hash(n.BlockHash(0)), genesis)
+	assert.Equal(t, Hash(n.BlockHash(1)), finalized)
+	assert.Equal(t, uint64(7), nonce)
+	assert.Equal(t, []byte{2}, value)
+	assert.Nil(t, missing)
+
+	// a failed request fails the batch
+	b = NewBatch(c)
+	b.BlockHash(0, &genesis)
+	b.Metadata(Hash{1}, &meta)
+	assert.Error(t, b.Send(context.Background()))
+}
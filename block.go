@@ -0,0 +1,173 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Header is a block header as returned by chain_getHeader
+type Header struct {
+	ParentHash     Hash
+	Number         uint64
+	StateRoot      Hash
+	ExtrinsicsRoot Hash
+	// Digest holds the encoded digest items
+	Digest [][]byte
+}
+
+type jsonHeader struct {
+	ParentHash     Hash           `json:"parentHash"`
+	Number         hexutil.Uint64 `json:"number"`
+	StateRoot      Hash           `json:"stateRoot"`
+	ExtrinsicsRoot Hash           `json:"extrinsicsRoot"`
+	Digest         struct {
+		Logs []hexutil.Bytes `json:"logs"`
+	} `json:"digest"`
+}
+
+func (h *Header) UnmarshalJSON(b []byte) error {
+	var jh jsonHeader
+	err := json.Unmarshal(b, &jh)
+	if err != nil {
+		return err
+	}
+
+	h.ParentHash = jh.ParentHash
+	h.Number = uint64(jh.Number)
+	h.StateRoot = jh.StateRoot
+	h.ExtrinsicsRoot = jh.ExtrinsicsRoot
+	h.Digest = make([][]byte, len(jh.Digest.Logs))
+	for i, l := range jh.Digest.Logs {
+		h.Digest[i] = l
+	}
+	return nil
+}
+
+// Hash of the header, the block hash
+func (h *Header) Hash() Hash {
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(h)
+	b := blake2b.Sum256(bb.Bytes())
+	return b[:]
+}
+
+func (h Header) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(h.ParentHash)
+	encoder.EncodeUintCompact(h.Number)
+	encoder.Write(h.StateRoot)
+	encoder.Write(h.ExtrinsicsRoot)
+	// the digest items are already encoded
+	encoder.EncodeUintCompact(uint64(len(h.Digest)))
+	for _, d := range h.Digest {
+		encoder.Write(d)
+	}
+}
+
+func (h *Header) ParityDecode(decoder scalecodec.Decoder) {
+	h.ParentHash = make(Hash, 32)
+	decoder.Read(h.ParentHash)
+	h.Number = decoder.DecodeUintCompact()
+	h.StateRoot = make(Hash, 32)
+	decoder.Read(h.StateRoot)
+	h.ExtrinsicsRoot = make(Hash, 32)
+	decoder.Read(h.ExtrinsicsRoot)
+	n := decoder.DecodeUintCompact()
+	h.Digest = make([][]byte, 0, n)
+	for i := uint64(0); i < n; i++ {
+		h.Digest = append(h.Digest, decodeDigestItem(decoder))
+	}
+}
+
+// decodeDigestItem reads a digest item and returns it encoded, the digest items are kept encoded in the header
+func decodeDigestItem(decoder scalecodec.Decoder) []byte {
+	var bb bytes.Buffer
+	encoder := scalecodec.NewEncoder(&bb)
+	b := decoder.ReadOneByte()
+	encoder.PushByte(b)
+	switch b {
+	case 0:
+		// Other
+		var v []byte
+		decoder.Decode(&v)
+		encoder.Encode(v)
+	case 1:
+		// AuthoritiesChange of the older runtimes, the fixed size authority IDs are not length prefixed
+		n := decoder.DecodeUintCompact()
+		encoder.EncodeUintCompact(n)
+		v := make([]byte, 32)
+		for i := uint64(0); i < n; i++ {
+			decoder.Read(v)
+			encoder.Write(v)
+		}
+	case 2:
+		// ChangesTrieRoot
+		v := make([]byte, 32)
+		decoder.Read(v)
+		encoder.Write(v)
+	case 3:
+		// Seal of the older runtimes, slot and signature
+		v := make([]byte, 8+64)
+		decoder.Read(v)
+		encoder.Write(v)
+	case 4, 5, 6:
+		// Consensus, Seal and PreRuntime with the consensus engine ID
+		id := make([]byte, 4)
+		decoder.Read(id)
+		var v []byte
+		decoder.Decode(&v)
+		encoder.Write(id)
+		encoder.Encode(v)
+	case 8:
+		// RuntimeEnvironmentUpdated
+	default:
+		panic(fmt.Sprintf("unknown digest item %d", b))
+	}
+	return bb.Bytes()
+}
+
+// Block with the decoded extrinsics
+type Block struct {
+	Header     Header
+	Extrinsics []Extrinsic
+}
+
+// SignedBlock is a block with the justification as returned by chain_getBlock
+type SignedBlock struct {
+	Block         Block
+	Justification []byte
+}
+
+// jsonSignedBlock holds the encoded extrinsics before they are decoded using the metadata
+type jsonSignedBlock struct {
+	Block struct {
+		Header     Header          `json:"header"`
+		Extrinsics []hexutil.Bytes `json:"extrinsics"`
+	} `json:"block"`
+	Justification *hexutil.Bytes `json:"justification"`
+}
+
+// decode the extrinsics of the block using the type registry and the metadata of the block
+func (jb *jsonSignedBlock) decode(registry *TypeRegistry, meta *MetadataVersioned) (*SignedBlock, error) {
+	sb := &SignedBlock{
+		Block: Block{Header: jb.Block.Header, Extrinsics: make([]Extrinsic, 0, len(jb.Block.Extrinsics))},
+	}
+
+	if jb.Justification != nil {
+		sb.Justification = *jb.Justification
+	}
+
+	for _, eb := range jb.Block.Extrinsics {
+		e, err := registry.DecodeExtrinsic(eb, meta)
+		if err != nil {
+			return nil, err
+		}
+		sb.Block.Extrinsics = append(sb.Block.Extrinsics, *e)
+	}
+
+	return sb, nil
+}
@@ -0,0 +1,263 @@
+package substrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultRangeRetries is the number of retries of a failed block fetch unless configured
+	defaultRangeRetries = 3
+	// defaultRangeRetryInterval is the wait before the first retry of a failed block fetch unless configured
+	defaultRangeRetryInterval = 100 * time.Millisecond
+)
+
+// BlockRangeConfig configures the fetching of a block range
+type BlockRangeConfig struct {
+	// Parallelism is the number of blocks fetched concurrently, defaults to 1
+	Parallelism int
+	// MaxRetries of a failed fetch before the iteration stops, defaults to 3, negative disables the retries
+	MaxRetries int
+	// RetryInterval is the wait before the first retry, doubled on every retry, defaults to 100ms
+	RetryInterval time.Duration
+}
+
+// RangeBlock is a block of a range with its metadata and events
+type RangeBlock struct {
+	Number uint64
+	Hash   Hash
+	// Metadata is shared by the blocks of the same spec version
+	Metadata *MetadataVersioned
+	Block    *SignedBlock
+	Events   []EventRecord
+}
+
+type rangeFetch struct {
+	block *RangeBlock
+	err   error
+}
+
+type rangeJob struct {
+	number uint64
+	res    chan rangeFetch
+}
+
+// BlockRangeIterator fetches the blocks from..to concurrently and delivers them in order.
+//
+//	it := chain.BlockRange(ctx, from, to, config)
+//	defer it.Close()
+//	for it.Next() {
+//		b := it.Block()
+//	}
+//	// resume later from it.Checkpoint()
+//	err := it.Err()
+type BlockRangeIterator struct {
+	chain  *Chain
+	state  *State
+	config BlockRangeConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// ordered holds the pending results in block order
+	ordered chan chan rangeFetch
+	current *RangeBlock
+	next    uint64
+	err     error
+
+	// metadata caches the metadata by spec version, it is only fetched again after a runtime upgrade
+	metaMu   sync.Mutex
+	metadata map[uint32]*MetadataVersioned
+}
+
+// BlockRange starts fetching the block hash, block, metadata and events of the blocks from..to (inclusive).
+// To resume an iteration start a new one from the Checkpoint of the previous one.
+func (c *Chain) BlockRange(ctx context.Context, from, to uint64, config BlockRangeConfig) *BlockRangeIterator {
+	if config.Parallelism < 1 {
+		config.Parallelism = 1
+	}
+	if config.MaxRetries == 0 {
+		config.MaxRetries = defaultRangeRetries
+	}
+	if config.RetryInterval <= 0 {
+		config.RetryInterval = defaultRangeRetryInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	it := &BlockRangeIterator{
+		chain:    c,
+		state:    NewStateRPC(c.client),
+		config:   config,
+		ctx:      ctx,
+		cancel:   cancel,
+		ordered:  make(chan chan rangeFetch, config.Parallelism),
+		next:     from,
+		metadata: make(map[uint32]*MetadataVersioned),
+	}
+
+	jobs := make(chan rangeJob)
+	go func() {
+		defer close(it.ordered)
+		defer close(jobs)
+		for n := from; n <= to; n++ {
+			j := rangeJob{number: n, res: make(chan rangeFetch, 1)}
+			select {
+			case it.ordered <- j.res:
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case jobs <- j:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for i := 0; i < config.Parallelism; i++ {
+		go func() {
+			for j := range jobs {
+				b, err := it.fetchWithRetry(j.number)
+				j.res <- rangeFetch{block: b, err: err}
+			}
+		}()
+	}
+
+	return it
+}
+
+// Next waits for the next block in order, returns false when the range is complete, the context is cancelled
+// or a block could not be fetched
+func (it *BlockRangeIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	// no block is delivered once cancelled, so that the checkpoint is the first block not seen
+	if err := it.ctx.Err(); err != nil {
+		it.err = err
+		return false
+	}
+
+	var res chan rangeFetch
+	var ok bool
+	select {
+	case res, ok = <-it.ordered:
+		if !ok {
+			// the range is complete unless the feeder stopped due to cancellation
+			it.err = it.ctx.Err()
+			return false
+		}
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+		return false
+	}
+
+	select {
+	case f := <-res:
+		if f.err != nil {
+			it.err = f.err
+			it.cancel()
+			return false
+		}
+		it.current = f.block
+		it.next = f.block.Number + 1
+		return true
+	case <-it.ctx.Done():
+		it.err = it.ctx.Err()
+		return false
+	}
+}
+
+// Block returns the current block
+func (it *BlockRangeIterator) Block() *RangeBlock {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration
+func (it *BlockRangeIterator) Err() error {
+	return it.err
+}
+
+// Checkpoint returns the number of the next block to be delivered
+func (it *BlockRangeIterator) Checkpoint() uint64 {
+	return it.next
+}
+
+// Close stops the fetching of the remaining blocks
+func (it *BlockRangeIterator) Close() {
+	it.cancel()
+}
+
+func (it *BlockRangeIterator) fetchWithRetry(number uint64) (*RangeBlock, error) {
+	wait := it.config.RetryInterval
+	for i := 0; ; i++ {
+		b, err := it.fetch(number)
+		if err == nil || i >= it.config.MaxRetries {
+			return b, err
+		}
+
+		select {
+		case <-time.After(wait):
+		case <-it.ctx.Done():
+			return nil, it.ctx.Err()
+		}
+		wait *= 2
+	}
+}
+
+func (it *BlockRangeIterator) fetch(number uint64) (*RangeBlock, error) {
+	if err := it.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	h, err := it.chain.BlockHash(it.ctx, number)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(h) == 0 {
+		return nil, fmt.Errorf("block %d not found", number)
+	}
+
+	meta, err := it.metadataAt(h)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := it.chain.Block(it.ctx, h, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := it.chain.Events(it.ctx, h, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RangeBlock{Number: number, Hash: h, Metadata: meta, Block: b, Events: events}, nil
+}
+
+// metadataAt returns the metadata of the block, fetched only for the spec versions not seen yet
+func (it *BlockRangeIterator) metadataAt(h Hash) (*MetadataVersioned, error) {
+	v, err := it.state.RuntimeVersion(it.ctx, h)
+	if err != nil {
+		return nil, err
+	}
+
+	it.metaMu.Lock()
+	defer it.metaMu.Unlock()
+	if meta, ok := it.metadata[v.SpecVersion]; ok {
+		return meta, nil
+	}
+
+	meta, err := it.state.MetaData(it.ctx, h)
+	if err != nil {
+		return nil, err
+	}
+
+	it.metadata[v.SpecVersion] = meta
+	return meta, nil
+}
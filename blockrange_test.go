@@ -0,0 +1,172 @@
+package substrate
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+// serveTestChain serves blocks 0..n with one timestamp.set inherent and its success event each
+func serveTestChain(m *mockClient, n uint64) {
+	hashOf := func(number uint64) string {
+		h := make([]byte, 32)
+		h[31] = byte(number)
+		return hexutil.Encode(h)
+	}
+
+	m.handle("chain_getBlockHash", func(args ...interface{}) (interface{}, error) {
+		number := args[0].(uint64)
+		if number > n {
+			return nil, nil
+		}
+		return hashOf(number), nil
+	})
+	m.handle("state_getRuntimeVersion", func(args ...interface{}) (interface{}, error) {
+		return substratetest.RuntimeVersion{SpecName: "node", SpecVersion: 1}, nil
+	})
+	m.handle("state_getMetadata", func(args ...interface{}) (interface{}, error) {
+		return substratetest.MetadataV4, nil
+	})
+	m.handle("chain_getBlock", func(args ...interface{}) (interface{}, error) {
+		h, _ := hexutil.Decode(args[0].(string))
+		number := uint64(h[31])
+		return map[string]interface{}{
+			"block": map[string]interface{}{
+				"header": map[string]interface{}{
+					"parentHash":     hashOf(number - 1),
+					"number":         hexutil.EncodeUint64(number),
+					"stateRoot":      hashOf(0),
+					"extrinsicsRoot": hashOf(0),
+					"digest":         map[string]interface{}{"logs": []string{}},
+				},
+				// timestamp.set(0)
+				"extrinsics": []string{"0x1001000000"},
+			},
+			"justification": nil,
+		}, nil
+	})
+	m.handle("state_getStorage", func(args ...interface{}) (interface{}, error) {
+		return hexutil.Encode(encodeTestEvents(true, []uint32{0}, [][]byte{{0, 0}})), nil
+	})
+}
+
+func TestChain_BlockRange(t *testing.T) {
+	m := newMockClient()
+	serveTestChain(m, 20)
+	it := NewChainRPC(m).BlockRange(context.Background(), 5, 20, BlockRangeConfig{Parallelism: 4})
+	defer it.Close()
+
+	expected := uint64(5)
+	for it.Next() {
+		b := it.Block()
+		assert.Equal(t, expected, b.Number)
+		assert.Equal(t, expected, b.Block.Block.Header.Number)
+		assert.Len(t, b.Block.Block.Extrinsics, 1)
+		assert.Len(t, b.Events, 1)
+		expected++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, uint64(21), expected)
+	assert.Equal(t, uint64(21), it.Checkpoint())
+	// the metadata is fetched once for the spec version
+	assert.Equal(t, 1, m.callCount("state_getMetadata"))
+	assert.Equal(t, 16, m.callCount("state_getRuntimeVersion"))
+}
+
+func TestChain_BlockRange_RuntimeUpgrade(t *testing.T) {
+	m := newMockClient()
+	serveTestChain(m, 20)
+	m.handle("state_getRuntimeVersion", func(args ...interface{}) (interface{}, error) {
+		h, _ := hexutil.Decode(args[0].(string))
+		v := substratetest.RuntimeVersion{SpecName: "node", SpecVersion: 1}
+		if h[31] >= 10 {
+			v.SpecVersion = 2
+		}
+		return v, nil
+	})
+
+	it := NewChainRPC(m).BlockRange(context.Background(), 0, 20, BlockRangeConfig{Parallelism: 4})
+	defer it.Close()
+	var metas []*MetadataVersioned
+	for it.Next() {
+		metas = append(metas, it.Block().Metadata)
+	}
+	assert.NoError(t, it.Err())
+	assert.Len(t, metas, 21)
+	assert.Equal(t, 2, m.callCount("state_getMetadata"))
+	assert.True(t, metas[0] == metas[9])
+	assert.True(t, metas[10] == metas[20])
+	assert.False(t, metas[9] == metas[10])
+}
+
+func TestChain_BlockRange_Retry(t *testing.T) {
+	m := newMockClient()
+	serveTestChain(m, 10)
+	var mu sync.Mutex
+	failures := map[string]int{}
+	m.handle("state_getStorage", func(args ...interface{}) (interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		h := args[1].(string)
+		if failures[h] < 2 {
+			failures[h]++
+			return nil, errors.New("temporary failure")
+		}
+		return hexutil.Encode(encodeTestEvents(true, []uint32{0}, [][]byte{{0, 0}})), nil
+	})
+
+	it := NewChainRPC(m).BlockRange(context.Background(), 0, 10, BlockRangeConfig{Parallelism: 3, MaxRetries: 2, RetryInterval: time.Millisecond})
+	var count int
+	for it.Next() {
+		count++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, 11, count)
+
+	// blocks beyond the head fail after the retries, the checkpoint allows to resume
+	hashes := m.callCount("chain_getBlockHash")
+	it = NewChainRPC(m).BlockRange(context.Background(), 8, 11, BlockRangeConfig{Parallelism: 1, RetryInterval: time.Millisecond})
+	for it.Next() {
+	}
+	assert.EqualError(t, it.Err(), "block 11 not found")
+	assert.Equal(t, uint64(11), it.Checkpoint())
+	// 8, 9, 10 and block 11 with the default retries
+	assert.Equal(t, hashes+3+1+defaultRangeRetries, m.callCount("chain_getBlockHash"))
+
+	// negative retries fail on the first error
+	hashes = m.callCount("chain_getBlockHash")
+	it = NewChainRPC(m).BlockRange(context.Background(), 11, 11, BlockRangeConfig{MaxRetries: -1})
+	assert.False(t, it.Next())
+	assert.Error(t, it.Err())
+	assert.Equal(t, uint64(11), it.Checkpoint())
+	assert.Equal(t, hashes+1, m.callCount("chain_getBlockHash"))
+}
+
+func TestChain_BlockRange_Cancel(t *testing.T) {
+	m := newMockClient()
+	serveTestChain(m, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	it := NewChainRPC(m).BlockRange(ctx, 0, 100, BlockRangeConfig{Parallelism: 2})
+	assert.True(t, it.Next())
+	assert.Equal(t, uint64(0), it.Block().Number)
+	cancel()
+	assert.False(t, it.Next())
+	assert.Equal(t, context.Canceled, it.Err())
+	assert.Equal(t, uint64(1), it.Checkpoint())
+
+	// the resumed iteration starts with the first block not delivered
+	it = NewChainRPC(m).BlockRange(context.Background(), it.Checkpoint(), 100, BlockRangeConfig{Parallelism: 2})
+	expected := uint64(1)
+	for it.Next() {
+		assert.Equal(t, expected, it.Block().Number)
+		expected++
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, uint64(101), expected)
+}
@@ -0,0 +1,255 @@
+package substrate
+
+import (
+	"container/list"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/crypto/blake2b"
+)
+
+// immutableMethods are the methods whose response never changes for a block hash, by the position of the block
+// hash in the arguments
+var immutableMethods = map[string]int{
+	"state_getMetadata":       0,
+	"state_getRuntimeVersion": 0,
+	"state_getStorage":        1,
+	"chain_getBlock":          0,
+	"chain_getHeader":         0,
+}
+
+// CacheConfig configures a CachingClient
+type CacheConfig struct {
+	// Size is the number of responses kept in memory, 1024 by default
+	Size int
+	// Dir is the directory storing the responses on disk when set, so that they are kept across restarts
+	Dir string
+}
+
+// CacheStats are the hits and misses of a CachingClient
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type cacheEntry struct {
+	key    string
+	result json.RawMessage
+}
+
+// CachingClient caches the responses of the queries at a block hash, eg: state_getMetadata(hash), in a LRU memory
+// cache and optionally on disk. The queries without a block hash and the empty responses, eg: of an unknown
+// block, are not cached.
+type CachingClient struct {
+	client Client
+	size   int
+	dir    string
+
+	mu      sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	stats   CacheStats
+}
+
+// NewCachingClient caches the immutable queries of the client, the directory of the disk store is created if missing
+func NewCachingClient(client Client, config CacheConfig) (*CachingClient, error) {
+	if config.Size <= 0 {
+		config.Size = 1024
+	}
+
+	if config.Dir != "" {
+		err := os.MkdirAll(config.Dir, 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &CachingClient{
+		client:  client,
+		size:    config.Size,
+		dir:     config.Dir,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}, nil
+}
+
+// Stats returns the hits and misses of the cacheable queries
+func (c *CachingClient) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// cacheKey returns the key of the query, false if the query is not cacheable
+func cacheKey(method string, args []interface{}) (string, bool) {
+	i, ok := immutableMethods[method]
+	if !ok || i >= len(args) {
+		return "", false
+	}
+
+	if h, ok := args[i].(string); !ok || h == "" {
+		return "", false
+	}
+
+	params, err := recordParams(args)
+	if err != nil {
+		return "", false
+	}
+	return requestKey(method, params), true
+}
+
+func (c *CachingClient) path(key string) string {
+	h := blake2b.Sum256([]byte(key))
+	return filepath.Join(c.dir, hex.EncodeToString(h[:])+".json")
+}
+
+func (c *CachingClient) get(key string) (json.RawMessage, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		c.stats.Hits++
+		return e.Value.(*cacheEntry).result, true
+	}
+
+	if c.dir != "" {
+		b, err := ioutil.ReadFile(c.path(key))
+		if err == nil && json.Valid(b) {
+			c.add(key, b)
+			c.stats.Hits++
+			return b, true
+		}
+	}
+
+	c.stats.Misses++
+	return nil, false
+}
+
+// add adds the result to the memory cache, the lock must be held
+func (c *CachingClient) add(key string, result json.RawMessage) {
+	if e, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(e)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, result: result})
+	if c.lru.Len() > c.size {
+		e := c.lru.Back()
+		c.lru.Remove(e)
+		delete(c.entries, e.Value.(*cacheEntry).key)
+	}
+}
+
+// put caches the result, the disk store is best effort and its write errors are ignored
+func (c *CachingClient) put(key string, result json.RawMessage) {
+	if len(result) == 0 || string(result) == "null" {
+		return
+	}
+
+	c.mu.Lock()
+	c.add(key, result)
+	c.mu.Unlock()
+
+	if c.dir != "" {
+		// write and rename so that a reader never sees a partial response
+		tmp, err := ioutil.TempFile(c.dir, "tmp-")
+		if err != nil {
+			return
+		}
+		_, err = tmp.Write(result)
+		cerr := tmp.Close()
+		if err != nil || cerr != nil || os.Rename(tmp.Name(), c.path(key)) != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}
+}
+
+func (c *CachingClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *CachingClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	key, ok := cacheKey(method, args)
+	if !ok {
+		return c.client.CallContext(ctx, result, method, args...)
+	}
+
+	raw, ok := c.get(key)
+	if !ok {
+		err := c.client.CallContext(ctx, &raw, method, args...)
+		if err != nil {
+			return err
+		}
+		c.put(key, raw)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func (c *CachingClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+// BatchCallContext serves the cached queries of the batch and sends the others in a single batch
+func (c *CachingClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	var batch []rpc.BatchElem
+	var sent []int
+	keys := make([]string, len(b))
+	raw := make([]json.RawMessage, len(b))
+	for i, e := range b {
+		key, ok := cacheKey(e.Method, e.Args)
+		if ok {
+			keys[i] = key
+			if res, ok := c.get(key); ok {
+				if e.Result != nil {
+					b[i].Error = json.Unmarshal(res, e.Result)
+				}
+				continue
+			}
+		}
+
+		batch = append(batch, rpc.BatchElem{Method: e.Method, Args: e.Args, Result: &raw[i]})
+		sent = append(sent, i)
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	err := c.client.BatchCallContext(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	for j, i := range sent {
+		if batch[j].Error != nil {
+			b[i].Error = batch[j].Error
+			continue
+		}
+
+		if keys[i] != "" {
+			c.put(keys[i], raw[i])
+		}
+		if b[i].Result != nil {
+			b[i].Error = json.Unmarshal(raw[i], b[i].Result)
+		}
+	}
+	return nil
+}
+
+func (c *CachingClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	return c.client.Subscribe(ctx, methods, channel, args...)
+}
+
+func (c *CachingClient) Close() {
+	c.client.Close()
+}
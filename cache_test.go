@@ -0,0 +1,92 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestCachingClient_MetaData(t *testing.T) {
+	m := newMockClient()
+	m.handle("state_getMetadata", func(args ...interface{}) (interface{}, error) {
+		return substratetest.MetadataV4, nil
+	})
+
+	c, err := NewCachingClient(m, CacheConfig{Size: 1})
+	assert.NoError(t, err)
+	s := NewStateRPC(c)
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		meta, err := s.MetaData(ctx, Hash{1})
+		assert.NoError(t, err)
+		assert.Equal(t, testMetadata(t), meta)
+	}
+	assert.Equal(t, 1, m.callCount("state_getMetadata"))
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 1}, c.Stats())
+
+	// the best block is not cached
+	_, err = s.MetaData(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, m.callCount("state_getMetadata"))
+
+	// evicts the least recently used
+	_, err = s.MetaData(ctx, Hash{2})
+	assert.NoError(t, err)
+	_, err = s.MetaData(ctx, Hash{1})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, m.callCount("state_getMetadata"))
+}
+
+func TestCachingClient_Disk(t *testing.T) {
+	m := newMockClient()
+	m.handle("chain_getHeader", func(args ...interface{}) (interface{}, error) {
+		if args[0].(string) == "0x02" {
+			return nil, nil
+		}
+		return map[string]interface{}{"number": "0x1", "digest": map[string]interface{}{"logs": []string{}}}, nil
+	})
+
+	dir := t.TempDir()
+	c, err := NewCachingClient(m, CacheConfig{Dir: dir})
+	assert.NoError(t, err)
+	h, err := NewChainRPC(c).Header(context.Background(), Hash{1})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), h.Number)
+
+	// unknown blocks are not cached
+	for i := 0; i < 2; i++ {
+		_, err = NewChainRPC(c).Header(context.Background(), Hash{2})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 3, m.callCount("chain_getHeader"))
+
+	c, err = NewCachingClient(m, CacheConfig{Dir: dir})
+	assert.NoError(t, err)
+	cached, err := NewChainRPC(c).Header(context.Background(), Hash{1})
+	assert.NoError(t, err)
+	assert.Equal(t, h, cached)
+	assert.Equal(t, 3, m.callCount("chain_getHeader"))
+}
+
+func TestCachingClient_StorageMulti(t *testing.T) {
+	m := newMockClient()
+	m.handle("state_getStorage", func(args ...interface{}) (interface{}, error) {
+		if args[0].(string) == "0x01" {
+			return "0x0102", nil
+		}
+		return nil, nil
+	})
+
+	c, err := NewCachingClient(m, CacheConfig{})
+	assert.NoError(t, err)
+	s := NewStateRPC(c)
+	for i := 0; i < 2; i++ {
+		values, err := s.StorageMulti(context.Background(), [][]byte{{1}, {2}}, Hash{1})
+		assert.NoError(t, err)
+		assert.Equal(t, [][]byte{{1, 2}, nil}, values)
+	}
+	assert.Equal(t, 2, m.batches)
+	assert.Equal(t, 3, m.callCount("state_getStorage"))
+}
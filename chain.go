@@ -1 +1,72 @@
 package substrate
+
+import "context"
+
+// Chain exposes the chain_* RPCs
+type Chain struct {
+	client   Client
+	registry *TypeRegistry
+}
+
+func NewChainRPC(client Client) *Chain {
+	return &Chain{client: client, registry: NewTypeRegistry()}
+}
+
+// TypeRegistry used to decode the extrinsics of the blocks, register custom types and calls here
+func (c *Chain) TypeRegistry() *TypeRegistry {
+	return c.registry
+}
+
+// BlockHash chain_getBlockHash
+func (c *Chain) BlockHash(ctx context.Context, blockNumber uint64) (Hash, error) {
+	var res Hash
+	err := c.client.CallContext(ctx, &res, "chain_getBlockHash", blockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// FinalizedHead chain_getFinalizedHead
+func (c *Chain) FinalizedHead(ctx context.Context) (Hash, error) {
+	var res Hash
+	err := c.client.CallContext(ctx, &res, "chain_getFinalizedHead")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// Header chain_getHeader, returns the best header when the block hash is empty
+func (c *Chain) Header(ctx context.Context, blockHash Hash) (*Header, error) {
+	var res Header
+	err := c.client.CallContext(ctx, &res, "chain_getHeader", hashArgs(blockHash)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// Block chain_getBlock, returns the best block when the block hash is empty.
+// The extrinsics are decoded using the given metadata, which must be the metadata of the block.
+func (c *Chain) Block(ctx context.Context, blockHash Hash, meta *MetadataVersioned) (*SignedBlock, error) {
+	var res jsonSignedBlock
+	err := c.client.CallContext(ctx, &res, "chain_getBlock", hashArgs(blockHash)...)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.decode(c.registry, meta)
+}
+
+// hashArgs omits an empty block hash so that the node defaults to the best block
+func hashArgs(blockHash Hash) []interface{} {
+	if len(blockHash) == 0 {
+		return nil
+	}
+
+	return []interface{}{blockHash.String()}
+}
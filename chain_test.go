@@ -0,0 +1,81 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestChain_Node(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	heads := make(chan Header)
+	sub, err := c.Subscribe(ctx, NewHeadSubscription, heads)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	a := newTestAuthor(t, c)
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	produced := n.ProduceBlock()
+	head := <-heads
+	assert.Equal(t, uint64(1), head.Number)
+	assert.Equal(t, Hash(produced), head.Hash())
+
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	hash, err := chain.BlockHash(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Hash(produced), hash)
+	finalized, err := chain.FinalizedHead(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, finalized)
+
+	genesis, err := chain.BlockHash(ctx, 0)
+	assert.NoError(t, err)
+	header, err := chain.Header(ctx, hash)
+	assert.NoError(t, err)
+	assert.Equal(t, genesis, header.ParentHash)
+	assert.Equal(t, hash, header.Hash())
+
+	block, err := chain.Block(ctx, hash, testMetadata(t))
+	assert.NoError(t, err)
+	if assert.Len(t, block.Block.Extrinsics, 1) {
+		assert.Equal(t, a1, *block.Block.Extrinsics[0].Method.Args.(*testAnchorParams))
+	}
+
+	unknown, err := chain.BlockHash(ctx, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+}
+
+func TestState_Storage_Node(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	n.SetStorage([]byte{1}, []byte{1, 2})
+	block := n.ProduceBlock()
+	n.SetStorage([]byte{1}, nil)
+	n.ProduceBlock()
+
+	s := NewStateRPC(c)
+	v, err := s.Storage(context.Background(), []byte{1}, block)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, v)
+	v, err = s.Storage(context.Background(), []byte{1}, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+}
@@ -1,6 +1,8 @@
 package substrate
 
 import (
+	"encoding/json"
+
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/vimukthi-git/go-substrate/scalecodec"
 )
@@ -13,6 +15,26 @@ func (h *Hash) String() string {
 	return hexutil.Encode(b[:])
 }
 
+func (h *Hash) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	d, err := hexutil.Decode(s)
+	if err != nil {
+		return err
+	}
+
+	*h = d
+	return nil
+}
+
 /**
 const PREFIX_1BYTE = 0xef;
 const PREFIX_2BYTE = 0xfc;
@@ -2,21 +2,115 @@ package substrate
 
 import (
 	"context"
+	"strings"
+
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// SubscriptionMethods are the RPC methods of a subscription: the subscribe method returns the subscription ID, the
+// notifications are sent with the notification method and the unsubscribe method ends the subscription
+type SubscriptionMethods struct {
+	Subscribe    string
+	Notification string
+	Unsubscribe  string
+}
+
+// The subscriptions of the substrate nodes
+var (
+	NewHeadSubscription = SubscriptionMethods{
+		Subscribe: "chain_subscribeNewHead", Notification: "chain_newHead", Unsubscribe: "chain_unsubscribeNewHead"}
+	FinalizedHeadSubscription = SubscriptionMethods{Subscribe: "chain_subscribeFinalizedHeads",
+		Notification: "chain_finalizedHead", Unsubscribe: "chain_unsubscribeFinalizedHeads"}
+	JustificationSubscription = SubscriptionMethods{Subscribe: "grandpa_subscribeJustifications",
+		Notification: "grandpa_justifications", Unsubscribe: "grandpa_unsubscribeJustifications"}
+	// ExtrinsicSubscription submits the extrinsic and sends its status changes
+	ExtrinsicSubscription = SubscriptionMethods{Subscribe: "author_submitAndWatchExtrinsic",
+		Notification: "author_extrinsicUpdate", Unsubscribe: "author_unwatchExtrinsic"}
+)
+
 type Client interface {
 	Call(result interface{}, method string, args ...interface{}) error
 
-	Subscribe(ctx context.Context, namespace string, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error)
+	// CallContext is Call with a context, the request is abandoned when the context is cancelled
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+
+	// BatchCall sends all the requests in a single batch and waits for the responses, the errors of the
+	// individual requests are set in the BatchElem.Error
+	BatchCall(b []rpc.BatchElem) error
+
+	// BatchCallContext is BatchCall with a context
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+
+	// Subscribe sends the notifications of the subscription to the channel, the subscription is unsubscribed once the
+	// context is done
+	Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error)
+
+	// Close closes the connection, in-flight requests and subscriptions are cancelled
+	Close()
+}
+
+// Subscription is an active subscription
+type Subscription interface {
+	// Err returns the channel receiving the error that ended the subscription, the channel is closed on Unsubscribe
+	Err() <-chan error
+
+	Unsubscribe()
+}
+
+// rpcClient adapts rpc.Client to the Client interface for the HTTP and IPC endpoints, which don't support
+// subscriptions
+type rpcClient struct {
+	*rpc.Client
 }
 
-// Connect
+func (c rpcClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	return nil, rpc.ErrNotificationsUnsupported
+}
+
+// Connect connects to the node, the subscriptions are only supported by the websocket endpoints
 func Connect(url string) (Client, error) {
-	client, err := rpc.Dial(url)
+	return ConnectContext(context.Background(), url)
+}
+
+// ConnectContext is Connect with a context to cancel or time out the dialing
+func ConnectContext(ctx context.Context, url string) (Client, error) {
+	if strings.HasPrefix(url, "ws://") || strings.HasPrefix(url, "wss://") {
+		return dialWebsocket(ctx, url)
+	}
+
+	client, err := rpc.DialContext(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 
-	return client, nil
+	return rpcClient{client}, nil
+}
+
+// unsubscribeOnDone ends the subscription when the context is done, so that the cancellation of the context
+// propagates to the subscription and not only to the subscribe request. The error of the subscription is forwarded.
+func unsubscribeOnDone(ctx context.Context, sub Subscription) Subscription {
+	ds := &doneSubscription{Subscription: sub, err: make(chan error, 1)}
+	go func() {
+		defer close(ds.err)
+		select {
+		case <-ctx.Done():
+			sub.Unsubscribe()
+		case err, ok := <-sub.Err():
+			if ok {
+				ds.err <- err
+			}
+		}
+	}()
+
+	return ds
+}
+
+// doneSubscription is a subscription ended with its context
+type doneSubscription struct {
+	Subscription
+	err chan error
+}
+
+func (s *doneSubscription) Err() <-chan error {
+	return s.err
 }
\ No newline at end of file
@@ -0,0 +1,87 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// mockClient serves the calls using the handlers registered per method
+type mockClient struct {
+	mu       sync.Mutex
+	handlers map[string]func(args ...interface{}) (interface{}, error)
+	calls    map[string]int
+	batches  int
+}
+
+func newMockClient() *mockClient {
+	return &mockClient{handlers: make(map[string]func(args ...interface{}) (interface{}, error)), calls: make(map[string]int)}
+}
+
+func (m *mockClient) handle(method string, h func(args ...interface{}) (interface{}, error)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[method] = h
+}
+
+func (m *mockClient) callCount(method string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.calls[method]
+}
+
+func (m *mockClient) Call(result interface{}, method string, args ...interface{}) error {
+	return m.CallContext(context.Background(), result, method, args...)
+}
+
+func (m *mockClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	h, ok := m.handlers[method]
+	m.calls[method]++
+	m.mu.Unlock()
+	if !ok {
+		return errors.New("method not found")
+	}
+
+	res, err := h(args...)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, result)
+}
+
+func (m *mockClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	return nil, errors.New("subscriptions not supported")
+}
+
+func (m *mockClient) BatchCall(b []rpc.BatchElem) error {
+	return m.BatchCallContext(context.Background(), b)
+}
+
+func (m *mockClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	m.batches++
+	m.mu.Unlock()
+	for i := range b {
+		b[i].Error = m.CallContext(ctx, b[i].Result, b[i].Method, b[i].Args...)
+	}
+	return nil
+}
+
+func (m *mockClient) Close() {}
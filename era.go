@@ -0,0 +1,113 @@
+package substrate
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+// MortalEra is the validity period of an extrinsic, the extrinsic is valid from its birth block for Period blocks.
+// Period is a power of two between 4 and 65536, Phase is the position of the birth block in the period.
+type MortalEra struct {
+	Period uint64
+	Phase  uint64
+}
+
+// NewMortalEra creates the era valid for period blocks from the current block, the period is rounded to a power of
+// two between 4 and 65536 and the phase is quantized to fit its encoding
+func NewMortalEra(period, current uint64) MortalEra {
+	if period < 4 {
+		period = 4
+	}
+	if period > 1<<16 {
+		period = 1 << 16
+	}
+	// the next power of two
+	if period&(period-1) != 0 {
+		period = 1 << uint(bits.Len64(period))
+	}
+
+	phase := current % period
+	quantizeFactor := maxUint64(period>>12, 1)
+	return MortalEra{Period: period, Phase: phase / quantizeFactor * quantizeFactor}
+}
+
+// Birth is the first block the extrinsic is valid in, given the current block
+func (m MortalEra) Birth(current uint64) uint64 {
+	return (maxUint64(current, m.Phase)-m.Phase)/m.Period*m.Period + m.Phase
+}
+
+// Death is the first block the extrinsic is no longer valid in, given the current block
+func (m MortalEra) Death(current uint64) uint64 {
+	return m.Birth(current) + m.Period
+}
+
+func maxUint64(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// ExtrinsicEra is immortal unless IsMortalEra is set
+type ExtrinsicEra struct {
+	IsMortalEra bool
+	AsMortalEra MortalEra
+}
+
+// Birth is the first block the extrinsic is valid in, given the current block
+func (e ExtrinsicEra) Birth(current uint64) uint64 {
+	if !e.IsMortalEra {
+		return 0
+	}
+	return e.AsMortalEra.Birth(current)
+}
+
+// Death is the first block the extrinsic is no longer valid in, given the current block
+func (e ExtrinsicEra) Death(current uint64) uint64 {
+	if !e.IsMortalEra {
+		return math.MaxUint64
+	}
+	return e.AsMortalEra.Death(current)
+}
+
+func (e *ExtrinsicEra) ParityDecode(decoder scalecodec.Decoder) {
+	first := decoder.ReadOneByte()
+	if first == 0 {
+		*e = ExtrinsicEra{}
+		return
+	}
+
+	encoded := uint64(first) + uint64(decoder.ReadOneByte())<<8
+	period := uint64(2) << (encoded % 16)
+	quantizeFactor := maxUint64(period>>12, 1)
+	phase := (encoded >> 4) * quantizeFactor
+	if period < 4 || phase >= period {
+		panic(fmt.Sprintf("invalid mortal era, period %d and phase %d", period, phase))
+	}
+
+	*e = ExtrinsicEra{IsMortalEra: true, AsMortalEra: MortalEra{Period: period, Phase: phase}}
+}
+
+func (e ExtrinsicEra) ParityEncode(encoder scalecodec.Encoder) {
+	if !e.IsMortalEra {
+		encoder.PushByte(0)
+		return
+	}
+
+	m := e.AsMortalEra
+	quantizeFactor := maxUint64(m.Period>>12, 1)
+	trailingZeros := uint64(bits.TrailingZeros64(m.Period))
+	encoded := minUint64(15, maxUint64(1, trailingZeros-1)) | (m.Phase/quantizeFactor)<<4
+	encoder.PushByte(byte(encoded))
+	encoder.PushByte(byte(encoded >> 8))
+}
+
+func minUint64(a, b uint64) uint64 {
+	if a < b {
+		return a
+	}
+	return b
+}
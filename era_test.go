@@ -0,0 +1,74 @@
+package substrate
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+func encodeEra(e ExtrinsicEra) []byte {
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e)
+	return bb.Bytes()
+}
+
+func decodeEra(b []byte) ExtrinsicEra {
+	var e ExtrinsicEra
+	scalecodec.NewDecoder(bytes.NewReader(b)).Decode(&e)
+	return e
+}
+
+func TestExtrinsicEra_Immortal(t *testing.T) {
+	e := ExtrinsicEra{}
+	assert.Equal(t, []byte{0}, encodeEra(e))
+	assert.Equal(t, e, decodeEra([]byte{0}))
+	assert.Equal(t, uint64(0), e.Birth(1000))
+	assert.Equal(t, uint64(math.MaxUint64), e.Death(1000))
+}
+
+func TestExtrinsicEra_Mortal(t *testing.T) {
+	for _, c := range []struct {
+		period, current uint64
+		era             MortalEra
+		encoded         []byte
+	}{
+		{64, 42, MortalEra{64, 42}, []byte{0xa5, 0x02}},
+		{32768, 20000, MortalEra{32768, 20000}, []byte{0x4e, 0x9c}},
+		{4, 6, MortalEra{4, 2}, []byte{0x21, 0x00}},
+		// rounded to the next power of two and clamped
+		{50, 42, MortalEra{64, 42}, []byte{0xa5, 0x02}},
+		{1, 6, MortalEra{4, 2}, []byte{0x21, 0x00}},
+		{1 << 20, 20007, MortalEra{65536, 20000}, []byte{0x2f, 0x4e}},
+	} {
+		m := NewMortalEra(c.period, c.current)
+		assert.Equal(t, c.era, m)
+		e := ExtrinsicEra{IsMortalEra: true, AsMortalEra: m}
+		assert.Equal(t, c.encoded, encodeEra(e))
+		assert.Equal(t, e, decodeEra(c.encoded))
+	}
+}
+
+func TestMortalEra_BirthDeath(t *testing.T) {
+	m := NewMortalEra(4, 6)
+	assert.Equal(t, uint64(6), m.Birth(6))
+	assert.Equal(t, uint64(10), m.Death(6))
+	assert.Equal(t, uint64(6), m.Birth(9))
+	assert.Equal(t, uint64(10), m.Birth(11))
+	assert.Equal(t, uint64(2), m.Birth(2))
+
+	// the phase of a long period is quantized
+	m = NewMortalEra(32768, 20001)
+	assert.Equal(t, uint64(20000), m.Phase)
+	assert.Equal(t, uint64(20000), m.Birth(20001))
+	assert.Equal(t, uint64(52768), m.Death(20001))
+}
+
+func TestExtrinsicEra_DecodeInvalid(t *testing.T) {
+	assert.Panics(t, func() {
+		// period 4 with phase 15
+		decodeEra([]byte{0xf1, 0x00})
+	})
+}
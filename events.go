@@ -0,0 +1,188 @@
+package substrate
+
+import (
+	"fmt"
+
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+// EventIDX [moduleIndex, eventIndex] 16bits
+type EventIDX struct {
+	ModuleIndex uint8
+	EventIndex  uint8
+}
+
+func (e *EventIDX) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&e.ModuleIndex)
+	decoder.Decode(&e.EventIndex)
+}
+
+// Phase of the block execution the event was emitted in
+type Phase struct {
+	IsApplyExtrinsic bool
+	// ApplyExtrinsic is the index of the extrinsic in the block
+	ApplyExtrinsic   uint32
+	IsFinalization   bool
+	IsInitialization bool
+}
+
+func (p *Phase) ParityDecode(decoder scalecodec.Decoder) {
+	b := decoder.ReadOneByte()
+	switch b {
+	case 0:
+		p.IsApplyExtrinsic = true
+		decoder.Decode(&p.ApplyExtrinsic)
+	case 1:
+		p.IsFinalization = true
+	case 2:
+		p.IsInitialization = true
+	default:
+		panic(fmt.Sprintf("unknown phase %d", b))
+	}
+}
+
+// Event with the arguments decoded using EventMetadata.Args
+type Event struct {
+	EventIndex EventIDX
+	Module     string
+	Name       string
+	Args       []interface{}
+}
+
+// EventRecord is an item of the System.Events storage
+type EventRecord struct {
+	Phase  Phase
+	Event  Event
+	Topics []Hash
+}
+
+// dispatchErrorVariants are the variants of the DispatchError enum of the runtimes from metadata v11
+var dispatchErrorVariants = []string{"Other", "CannotLookup", "BadOrigin", "Module", "ConsumerRemaining", "NoProviders",
+	"Token", "Arithmetic"}
+
+// DispatchError is the reason an extrinsic failed, the argument of system.ExtrinsicFailed
+type DispatchError struct {
+	HasModule bool
+	Module    uint8
+	Error     uint8
+	// Variant is the variant of the DispatchError enum from metadata v11, eg: Module or BadOrigin. The Error of the
+	// Token and Arithmetic variants is the index of their reason.
+	Variant string
+}
+
+func (d *DispatchError) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.DecodeOption(&d.HasModule, &d.Module)
+	decoder.Decode(&d.Error)
+}
+
+// decodeV11 decodes the DispatchError enum of the runtimes from metadata v11
+func (d *DispatchError) decodeV11(decoder scalecodec.Decoder) {
+	b := decoder.ReadOneByte()
+	if int(b) >= len(dispatchErrorVariants) {
+		panic(fmt.Sprintf("unknown dispatch error %d", b))
+	}
+
+	d.Variant = dispatchErrorVariants[b]
+	switch d.Variant {
+	case "Module":
+		d.HasModule = true
+		decoder.Decode(&d.Module)
+		decoder.Decode(&d.Error)
+	case "Token", "Arithmetic":
+		decoder.Decode(&d.Error)
+	}
+}
+
+func (d DispatchError) String() string {
+	switch {
+	case d.HasModule:
+		return fmt.Sprintf("dispatch error %d in module %d", d.Error, d.Module)
+	case d.Variant == "Token" || d.Variant == "Arithmetic":
+		return fmt.Sprintf("dispatch error %s %d", d.Variant, d.Error)
+	case d.Variant != "":
+		return fmt.Sprintf("dispatch error %s", d.Variant)
+	}
+	return fmt.Sprintf("dispatch error %d", d.Error)
+}
+
+// DispatchInfo is the argument of system.ExtrinsicSuccess
+type DispatchInfo struct {
+	// Weight is a u32 before metadata v11
+	Weight uint64
+	// Class 0 - Normal, 1 - Operational, 2 - Mandatory
+	Class   uint8
+	PaysFee bool
+}
+
+func (d *DispatchInfo) ParityDecode(decoder scalecodec.Decoder) {
+	var weight uint32
+	decoder.Decode(&weight)
+	d.Weight = uint64(weight)
+	decoder.Decode(&d.Class)
+	decoder.Decode(&d.PaysFee)
+}
+
+// decodeV11 decodes the DispatchInfo of the runtimes from metadata v11, the fee is paid with the Pays::Yes variant
+func (d *DispatchInfo) decodeV11(decoder scalecodec.Decoder) {
+	decoder.Decode(&d.Weight)
+	decoder.Decode(&d.Class)
+	d.PaysFee = decoder.ReadOneByte() == 0
+}
+
+// DecodeEventRecords decodes the System.Events storage. Event records of older runtimes don't have topics,
+// both encodings are tried.
+func (r *TypeRegistry) DecodeEventRecords(b []byte, meta *MetadataVersioned) ([]EventRecord, error) {
+	records, err := r.decodeEventRecords(b, meta, true)
+	if err == nil {
+		return records, nil
+	}
+
+	records, errNoTopics := r.decodeEventRecords(b, meta, false)
+	if errNoTopics != nil {
+		return nil, err
+	}
+
+	return records, nil
+}
+
+func (r *TypeRegistry) decodeEventRecords(b []byte, meta *MetadataVersioned, withTopics bool) (records []EventRecord, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode events: %v", rec)
+		}
+	}()
+
+	c := r.newCallDecoder(b, meta)
+	n := c.decoder.DecodeUintCompact()
+	records = make([]EventRecord, 0, n)
+	for i := uint64(0); i < n; i++ {
+		rec := EventRecord{}
+		c.decoder.Decode(&rec.Phase)
+		c.decoder.Decode(&rec.Event.EventIndex)
+		mod, ev, err := meta.Metadata.FindEvent(rec.Event.EventIndex)
+		if err != nil {
+			return nil, err
+		}
+
+		rec.Event.Module = mod.Name
+		rec.Event.Name = ev.Name
+		rec.Event.Args = make([]interface{}, 0, len(ev.Args))
+		for _, t := range ev.Args {
+			rec.Event.Args = append(rec.Event.Args, c.value(t))
+		}
+
+		if withTopics {
+			topics := c.decoder.DecodeUintCompact()
+			for j := uint64(0); j < topics; j++ {
+				rec.Topics = append(rec.Topics, decodeHash(c.decoder).(Hash))
+			}
+		}
+		records = append(records, rec)
+	}
+
+	if c.reader.Len() != 0 {
+		return nil, fmt.Errorf("failed to decode events: %d bytes remaining", c.reader.Len())
+	}
+
+	return records, nil
+}
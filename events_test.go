@@ -0,0 +1,237 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+// encodeTestEvents encodes event records, the events must be encoded with the event index
+func encodeTestEvents(withTopics bool, phases []uint32, events [][]byte) []byte {
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	enc.EncodeUintCompact(uint64(len(events)))
+	for i, e := range events {
+		// ApplyExtrinsic
+		enc.PushByte(0)
+		enc.Encode(phases[i])
+		enc.Write(e)
+		if withTopics {
+			enc.EncodeUintCompact(0)
+		}
+	}
+	return bb.Bytes()
+}
+
+func testAnchorCommittedEvent() []byte {
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	// kerplunk.AnchorCommitted
+	enc.Write([]byte{4, 0})
+	enc.Write(make([]byte, 32))
+	enc.Write(make([]byte, 32))
+	enc.Write(make([]byte, 32))
+	enc.Encode(uint64(10))
+	return bb.Bytes()
+}
+
+func TestTypeRegistry_DecodeEventRecords(t *testing.T) {
+	meta := testMetadata(t)
+	r := NewTypeRegistry()
+	events := [][]byte{testAnchorCommittedEvent(), {0, 0}}
+	for _, withTopics := range []bool{true, false} {
+		records, err := r.DecodeEventRecords(encodeTestEvents(withTopics, []uint32{1, 1}, events), meta)
+		assert.NoError(t, err)
+		assert.Len(t, records, 2)
+		assert.Equal(t, "kerplunk", records[0].Event.Module)
+		assert.Equal(t, "AnchorCommitted", records[0].Event.Name)
+		assert.Equal(t, uint64(10), records[0].Event.Args[3])
+		assert.Equal(t, "ExtrinsicSuccess", records[1].Event.Name)
+		assert.True(t, records[1].Phase.IsApplyExtrinsic)
+		assert.Equal(t, uint32(1), records[1].Phase.ApplyExtrinsic)
+	}
+
+	_, err := r.DecodeEventRecords([]byte{4, 5}, meta)
+	assert.Error(t, err)
+}
+
+func TestNewExtrinsicReceipt(t *testing.T) {
+	meta := testMetadata(t)
+	r := NewTypeRegistry()
+	_, call := testAnchorCall()
+	sig := NewExtrinsicSignature(Signature{}, 0)
+	e, err := r.DecodeExtrinsic(encodeTestExtrinsic(&sig, call), meta)
+	assert.NoError(t, err)
+	b := &SignedBlock{Block: Block{Header: Header{Number: 10}, Extrinsics: []Extrinsic{{}, *e}}}
+
+	records, err := r.DecodeEventRecords(encodeTestEvents(true, []uint32{0, 1, 1}, [][]byte{{0, 0}, testAnchorCommittedEvent(), {0, 0}}), meta)
+	assert.NoError(t, err)
+	receipt, err := NewExtrinsicReceipt(Hash{1}, b, 1, records)
+	assert.NoError(t, err)
+	assert.True(t, receipt.Success)
+	assert.Equal(t, uint64(10), receipt.BlockNumber)
+	assert.Equal(t, e.Hash(), receipt.ExtrinsicHash)
+	assert.Len(t, receipt.Events, 2)
+
+	// failed
+	records, err = r.DecodeEventRecords(encodeTestEvents(true, []uint32{1}, [][]byte{{0, 1}}), meta)
+	assert.NoError(t, err)
+	receipt, err = NewExtrinsicReceipt(Hash{1}, b, 1, records)
+	assert.NoError(t, err)
+	assert.False(t, receipt.Success)
+
+	// no outcome
+	_, err = NewExtrinsicReceipt(Hash{1}, b, 0, records)
+	assert.Error(t, err)
+}
+
+func TestChain_Events_V11(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the system module is named System from v11
+	meta, err := decodeMetadata(encodeTestMetadata(t, 11, nil))
+	assert.NoError(t, err)
+	key, err := meta.Metadata.StorageKey("system", "Events")
+	assert.NoError(t, err)
+	n.SetStorage(key, encodeTestEvents(true, []uint32{0}, [][]byte{{0, 0}}))
+
+	ctx := context.Background()
+	records, err := NewChainRPC(c).Events(ctx, nil, meta)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "System", records[0].Event.Module)
+	assert.Equal(t, "ExtrinsicSuccess", records[0].Event.Name)
+
+	b := &SignedBlock{Block: Block{Header: Header{Number: 1}, Extrinsics: []Extrinsic{{}}}}
+	receipt, err := NewExtrinsicReceipt(Hash{1}, b, 0, records)
+	assert.NoError(t, err)
+	assert.True(t, receipt.Success)
+}
+
+func TestDispatchError_ParityDecode(t *testing.T) {
+	d := DispatchError{}
+	scalecodec.NewDecoder(bytes.NewReader([]byte{1, 3, 2})).Decode(&d)
+	assert.Equal(t, DispatchError{HasModule: true, Module: 3, Error: 2}, d)
+
+	// the enum of the runtimes from v11
+	for _, c := range []struct {
+		b   []byte
+		err DispatchError
+	}{
+		{[]byte{2}, DispatchError{Variant: "BadOrigin"}},
+		{[]byte{3, 5, 3}, DispatchError{HasModule: true, Module: 5, Error: 3, Variant: "Module"}},
+		{[]byte{6, 1}, DispatchError{Error: 1, Variant: "Token"}},
+	} {
+		d = DispatchError{}
+		d.decodeV11(*scalecodec.NewDecoder(bytes.NewReader(c.b)))
+		assert.Equal(t, c.err, d)
+	}
+	assert.Equal(t, "dispatch error BadOrigin", DispatchError{Variant: "BadOrigin"}.String())
+	assert.Panics(t, func() {
+		d.decodeV11(*scalecodec.NewDecoder(bytes.NewReader([]byte{8})))
+	})
+}
+
+// failedEvents are the System.Events of a balances transfer failing with the module error 3 of balances (5), as
+// encoded by the runtimes whose system.ExtrinsicFailed carries a DispatchError and a DispatchInfo: the record in the
+// ApplyExtrinsic(0) phase, system.ExtrinsicFailed, DispatchError{module: Some(5), error: 3},
+// DispatchInfo{weight: 1000000, class: Normal, pays_fee: true} and no topics
+const failedEvents = "0x040000000000000101050340420f00000100"
+
+// failedEventsV12 are the System.Events of a balances transfer failing with the module error 3 of balances (5), as
+// encoded by the runtimes of the metadata v12: the record in the ApplyExtrinsic(1) phase, System.ExtrinsicFailed,
+// DispatchError::Module{index: 5, error: 3}, DispatchInfo{weight: 195000000 as a u64, class: Normal, pays_fee:
+// Pays::Yes} and no topics. The record is encoded by hand following the layouts of substrate 2.0.
+const failedEventsV12 = "0x0400010000000001030503c0769f0b00000000000000"
+
+// testMetadataDispatch returns the test metadata with the arguments of the system events of these runtimes
+func testMetadataDispatch(t *testing.T) *MetadataVersioned {
+	meta := testMetadata(t)
+	for i, m := range meta.Metadata.Modules {
+		if m.Name != "system" {
+			continue
+		}
+		meta.Metadata.Modules[i].Events[0].Args = []string{"DispatchInfo"}
+		meta.Metadata.Modules[i].Events[1].Args = []string{"DispatchError", "DispatchInfo"}
+	}
+	return meta
+}
+
+func TestTypeRegistry_DecodeEventRecords_V12(t *testing.T) {
+	meta, err := decodeMetadata(encodeTestMetadataOf(t, testMetadataDispatch(t), 12, nil))
+	assert.NoError(t, err)
+	records, err := NewTypeRegistry().DecodeEventRecords(hexutil.MustDecode(failedEventsV12), meta)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, Phase{IsApplyExtrinsic: true, ApplyExtrinsic: 1}, records[0].Phase)
+	assert.Equal(t, "System", records[0].Event.Module)
+	assert.Equal(t, "ExtrinsicFailed", records[0].Event.Name)
+	assert.Equal(t, []interface{}{DispatchError{HasModule: true, Module: 5, Error: 3, Variant: "Module"},
+		DispatchInfo{Weight: 195000000, Class: 0, PaysFee: true}}, records[0].Event.Args)
+	assert.Empty(t, records[0].Topics)
+
+	// the v4 layouts don't decode the record
+	_, err = NewTypeRegistry().DecodeEventRecords(hexutil.MustDecode(failedEventsV12), testMetadataDispatch(t))
+	assert.Error(t, err)
+
+	// the registered types take precedence over the layouts of the metadata version
+	r := NewTypeRegistry()
+	r.RegisterType("DispatchInfo", func(decoder scalecodec.Decoder) interface{} {
+		d := DispatchInfo{}
+		decoder.Decode(&d)
+		return d
+	})
+	_, err = r.DecodeEventRecords(hexutil.MustDecode(failedEventsV12), meta)
+	assert.Error(t, err)
+}
+
+func TestChain_ExtrinsicReceipt_Failed(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	meta := testMetadataDispatch(t)
+	key, err := meta.Metadata.StorageKey("system", "Events")
+	assert.NoError(t, err)
+	n.SetStorage(key, hexutil.MustDecode(failedEvents))
+	a1, _ := testAnchorCall()
+	_, err = newTestAuthor(t, c).SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	block := n.ProduceBlock()
+
+	rec := NewRecorder(c)
+	chain := NewChainRPC(rec)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	receipt, err := chain.ExtrinsicReceiptByIndex(ctx, nil, 0, meta)
+	assert.NoError(t, err)
+	assert.False(t, receipt.Success)
+	assert.Equal(t, &DispatchError{HasModule: true, Module: 5, Error: 3}, receipt.DispatchError)
+	assert.Equal(t, DispatchInfo{Weight: 1000000, Class: 0, PaysFee: true}, receipt.Events[0].Event.Args[1])
+	assert.Equal(t, Hash(block), receipt.BlockHash)
+
+	// the best block is resolved once, the block and its events are queried at its hash
+	calls := rec.Fixture().Calls
+	if assert.Len(t, calls, 3) {
+		assert.Equal(t, "chain_getBlockHash", calls[0].Method)
+		hash, _ := json.Marshal(hexutil.Encode(block))
+		assert.Equal(t, "chain_getBlock", calls[1].Method)
+		assert.Equal(t, "["+string(hash)+"]", string(calls[1].Params))
+		assert.Equal(t, "state_getStorage", calls[2].Method)
+		assert.Contains(t, string(calls[2].Params), string(hash))
+	}
+}
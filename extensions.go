@@ -0,0 +1,147 @@
+package substrate
+
+import (
+	"fmt"
+
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+// ExtensionParams are the values encoded by the signed extensions of an extrinsic
+type ExtensionParams struct {
+	Nonce              uint64
+	Era                ExtrinsicEra
+	Tip                uint64
+	SpecVersion        uint32
+	TransactionVersion uint32
+	GenesisHash        []byte
+	// BlockHash is the hash of the birth block of a mortal era, the genesis hash for an immortal era
+	BlockHash []byte
+}
+
+// SignedExtension encodes the data of a signed extension of the runtime. The extra data is included in the
+// extrinsic and in the signature payload, the additional signed data is only included in the signature payload.
+// Like the scalecodec encoders the methods panic when the data can't be encoded or decoded.
+type SignedExtension interface {
+	Extra(encoder scalecodec.Encoder, params ExtensionParams)
+	AdditionalSigned(encoder scalecodec.Encoder, params ExtensionParams)
+	// DecodeExtra reads the extra data of an extrinsic in to the params
+	DecodeExtra(decoder scalecodec.Decoder, params *ExtensionParams)
+}
+
+// signedExtension is a SignedExtension of functions, a nil function has no data
+type signedExtension struct {
+	extra            func(encoder scalecodec.Encoder, params ExtensionParams)
+	additionalSigned func(encoder scalecodec.Encoder, params ExtensionParams)
+	decodeExtra      func(decoder scalecodec.Decoder, params *ExtensionParams)
+}
+
+func (s signedExtension) Extra(encoder scalecodec.Encoder, params ExtensionParams) {
+	if s.extra != nil {
+		s.extra(encoder, params)
+	}
+}
+
+func (s signedExtension) AdditionalSigned(encoder scalecodec.Encoder, params ExtensionParams) {
+	if s.additionalSigned != nil {
+		s.additionalSigned(encoder, params)
+	}
+}
+
+func (s signedExtension) DecodeExtra(decoder scalecodec.Decoder, params *ExtensionParams) {
+	if s.decodeExtra != nil {
+		s.decodeExtra(decoder, params)
+	}
+}
+
+// writeHash writes a 32 bytes hash, padded with zeros
+func writeHash(encoder scalecodec.Encoder, h []byte) {
+	var b [32]byte
+	copy(b[:], h)
+	encoder.Write(b[:])
+}
+
+var checkMortality = signedExtension{
+	extra: func(encoder scalecodec.Encoder, params ExtensionParams) {
+		encoder.Encode(params.Era)
+	},
+	additionalSigned: func(encoder scalecodec.Encoder, params ExtensionParams) {
+		writeHash(encoder, params.BlockHash)
+	},
+	decodeExtra: func(decoder scalecodec.Decoder, params *ExtensionParams) {
+		decoder.Decode(&params.Era)
+	},
+}
+
+// defaultSignedExtensions are the signed extensions of the substrate runtime modules
+var defaultSignedExtensions = map[string]SignedExtension{
+	"CheckSpecVersion": signedExtension{
+		additionalSigned: func(encoder scalecodec.Encoder, params ExtensionParams) {
+			encoder.Encode(params.SpecVersion)
+		},
+	},
+	"CheckTxVersion": signedExtension{
+		additionalSigned: func(encoder scalecodec.Encoder, params ExtensionParams) {
+			encoder.Encode(params.TransactionVersion)
+		},
+	},
+	"CheckGenesis": signedExtension{
+		additionalSigned: func(encoder scalecodec.Encoder, params ExtensionParams) {
+			writeHash(encoder, params.GenesisHash)
+		},
+	},
+	"CheckEra":       checkMortality,
+	"CheckMortality": checkMortality,
+	"CheckNonce": signedExtension{
+		extra: func(encoder scalecodec.Encoder, params ExtensionParams) {
+			encoder.EncodeUintCompact(params.Nonce)
+		},
+		decodeExtra: func(decoder scalecodec.Decoder, params *ExtensionParams) {
+			params.Nonce = decoder.DecodeUintCompact()
+		},
+	},
+	"CheckWeight":        signedExtension{},
+	"CheckNonZeroSender": signedExtension{},
+	"CheckBlockGasLimit": signedExtension{},
+	"ChargeTransactionPayment": signedExtension{
+		extra: func(encoder scalecodec.Encoder, params ExtensionParams) {
+			encoder.EncodeUintCompact(params.Tip)
+		},
+		decodeExtra: func(decoder scalecodec.Decoder, params *ExtensionParams) {
+			params.Tip = decoder.DecodeUintCompact()
+		},
+	},
+}
+
+// RegisterSignedExtension registers a signed extension of the runtime by its identifier in the metadata,
+// eg: "CheckNonce". The default implementation of a substrate signed extension can be replaced.
+func (r *TypeRegistry) RegisterSignedExtension(name string, ext SignedExtension) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extensions[name] = ext
+}
+
+// signedExtensions returns the signed extensions in the order of the identifiers
+func (r *TypeRegistry) signedExtensions(names []string) ([]SignedExtension, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	exts := make([]SignedExtension, 0, len(names))
+	for _, n := range names {
+		ext, ok := r.extensions[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown signed extension %s", n)
+		}
+		exts = append(exts, ext)
+	}
+	return exts, nil
+}
+
+// ExtrinsicMetadata describes the format of the extrinsics, the signed extensions are listed from metadata v11
+type ExtrinsicMetadata struct {
+	Version          uint8
+	SignedExtensions []string
+}
+
+func (m *ExtrinsicMetadata) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&m.Version)
+	decoder.Decode(&m.SignedExtensions)
+}
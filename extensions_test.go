@@ -0,0 +1,127 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+var testSignedExtensions = []string{"CheckSpecVersion", "CheckTxVersion", "CheckGenesis", "CheckMortality",
+	"CheckNonce", "CheckWeight", "ChargeTransactionPayment"}
+
+// testEpoch is a custom signed extension with an extra byte and an additional signed epoch
+type testEpoch struct {
+	epoch uint32
+}
+
+func (t testEpoch) Extra(encoder scalecodec.Encoder, params ExtensionParams) {
+	encoder.PushByte(0xee)
+}
+
+func (t testEpoch) AdditionalSigned(encoder scalecodec.Encoder, params ExtensionParams) {
+	encoder.Encode(t.epoch)
+}
+
+func (t testEpoch) DecodeExtra(decoder scalecodec.Decoder, params *ExtensionParams) {
+	decoder.ReadOneByte()
+}
+
+func TestExtrinsic_SignaturePayload_Extensions(t *testing.T) {
+	exts, err := NewTypeRegistry().signedExtensions(testSignedExtensions)
+	assert.NoError(t, err)
+
+	a1, call := testAnchorCall()
+	genesis := bytes.Repeat([]byte{1}, 32)
+	birth := bytes.Repeat([]byte{2}, 32)
+	e := NewExtrinsic(Account{}, 5, birth, NewMethod("kerplunk.commit", a1, *testMetadata(t)))
+	e.Era = ExtrinsicEra{IsMortalEra: true, AsMortalEra: MortalEra{Period: 64, Phase: 42}}
+	e.Tip = 100
+	e.SpecVersion = 3
+	e.TransactionVersion = 4
+	e.GenesisHash = genesis
+	e.Extensions = exts
+
+	extra := []byte{0xa5, 0x02, 5 << 2, 0x91, 0x01}
+	assert.Equal(t, extra, e.extra())
+
+	var expected []byte
+	expected = append(expected, call...)
+	expected = append(expected, extra...)
+	expected = append(expected, 3, 0, 0, 0, 4, 0, 0, 0)
+	expected = append(expected, genesis...)
+	expected = append(expected, birth...)
+	assert.Equal(t, expected, e.SignaturePayload())
+}
+
+func TestTypeRegistry_SignedExtensions(t *testing.T) {
+	r := NewTypeRegistry()
+	_, err := r.signedExtensions([]string{"CheckNonce", "CheckEpoch"})
+	assert.EqualError(t, err, "unknown signed extension CheckEpoch")
+
+	r.RegisterSignedExtension("CheckEpoch", testEpoch{epoch: 7})
+	exts, err := r.signedExtensions([]string{"CheckNonce", "CheckEpoch"})
+	assert.NoError(t, err)
+
+	a1, call := testAnchorCall()
+	e := NewExtrinsic(Account{}, 1, nil, NewMethod("kerplunk.commit", a1, *testMetadata(t)))
+	e.Extensions = exts
+	assert.Equal(t, append(call, 1<<2, 0xee, 7, 0, 0, 0), e.SignaturePayload())
+}
+
+func TestAuthor_SubmitExtrinsic_Extensions(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	n.SetSignedExtensions(testSignedExtensions...)
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	meta := testMetadata(t)
+	meta.Extrinsic.SignedExtensions = testSignedExtensions
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	a := NewAuthorRPC(0, n.BlockHash(0), NewKeyringAccount(pair), *meta, c)
+	a.SetTip(100)
+	a.SetRuntimeVersion(1, 2)
+	a.SetMortality(64)
+	a1, _ := testAnchorCall()
+	for i := 0; i < 2; i++ {
+		_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+		assert.NoError(t, err)
+	}
+
+	block := n.ProduceBlock()
+	var account [32]byte
+	copy(account[:], pair.PublicKey())
+	assert.Equal(t, uint64(2), n.Nonce(account))
+
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(context.Background(), block, meta)
+	assert.NoError(t, err)
+	assert.Len(t, b.Block.Extrinsics, 2)
+	for i, e := range b.Block.Extrinsics {
+		assert.Equal(t, uint64(i), e.Nonce)
+		assert.Equal(t, uint64(100), e.Tip)
+		assert.Equal(t, MortalEra{Period: 64, Phase: 0}, e.Era.AsMortalEra)
+
+		// the extrinsic is signed with the additional signed data
+		e.SpecVersion = 1
+		e.TransactionVersion = 2
+		e.GenesisHash = n.BlockHash(0)
+		e.BestKnownBlock = n.BlockHash(0)
+		assert.True(t, pair.Verify(e.SignaturePayload(), e.Signature.Signature.Hash[:]))
+	}
+
+	meta.Extrinsic.SignedExtensions = []string{"CheckEpoch"}
+	a = NewAuthorRPC(0, n.BlockHash(0), NewKeyringAccount(pair), *meta, c)
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.EqualError(t, err, "unknown signed extension CheckEpoch")
+}
@@ -0,0 +1,336 @@
+package substrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// BalanceStrategy selects the endpoint of a call among the healthy endpoints
+type BalanceStrategy uint8
+
+const (
+	// RoundRobin rotates the calls over the healthy endpoints
+	RoundRobin BalanceStrategy = iota
+	// LeastLatency sends the calls to the healthy endpoint with the lowest average latency
+	LeastLatency
+)
+
+// ErrNoEndpoints is returned when all the endpoints failed a call
+var ErrNoEndpoints = errors.New("no endpoint available")
+
+// defaultMaxBlockLag is the number of blocks a healthy endpoint may be behind the best endpoint unless configured,
+// the endpoints import a new block at slightly different times
+const defaultMaxBlockLag = 3
+
+// FailoverConfig configures a FailoverClient
+type FailoverConfig struct {
+	Strategy BalanceStrategy
+	// HealthCheckInterval defaults to 10s
+	HealthCheckInterval time.Duration
+	// MinPeers is the minimum peer count of a healthy endpoint that should have peers
+	MinPeers uint64
+	// MaxBlockLag is the maximum number of blocks a healthy endpoint may be behind the best endpoint, defaults to 3
+	// when nil
+	MaxBlockLag *uint64
+}
+
+// Endpoint is a named connection of a FailoverClient
+type Endpoint struct {
+	Name   string
+	Client Client
+}
+
+// EndpointStatus is the state of an endpoint as of the last health check or call
+type EndpointStatus struct {
+	Name      string
+	Healthy   bool
+	Peers     uint64
+	IsSyncing bool
+	BestBlock uint64
+	// Latency is the moving average of the call durations
+	Latency time.Duration
+	// Err is the error of the last failed health check or call
+	Err error
+}
+
+type endpoint struct {
+	client Client
+	status EndpointStatus
+}
+
+// FailoverClient is a Client over several endpoints. The endpoints are health checked with system_health and
+// chain_getHeader, calls go to the healthy endpoints by the configured strategy and fail over to the next endpoint
+// on connection errors. Errors returned by a node are not failed over, nor are the author_* requests as the node may
+// have received the extrinsic before the connection failed. A subscription is pinned to the endpoint it was made on.
+type FailoverClient struct {
+	config      FailoverConfig
+	maxBlockLag uint64
+
+	mu        sync.Mutex
+	endpoints []*endpoint
+	next      int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// ConnectFailover connects to the given urls with reconnecting clients, endpoints that can't be reached at start
+// are skipped
+func ConnectFailover(ctx context.Context, urls []string, config FailoverConfig) (*FailoverClient, error) {
+	var endpoints []Endpoint
+	var err error
+	for _, url := range urls {
+		var c Client
+		c, err = ConnectWithReconnect(ctx, url, ReconnectConfig{})
+		if err != nil {
+			continue
+		}
+		endpoints = append(endpoints, Endpoint{Name: url, Client: c})
+	}
+
+	if len(endpoints) == 0 {
+		return nil, fmt.Errorf("failed to connect to any endpoint: %v", err)
+	}
+
+	return NewFailoverClient(ctx, endpoints, config)
+}
+
+// NewFailoverClient health checks the endpoints and starts the periodic health checks
+func NewFailoverClient(ctx context.Context, endpoints []Endpoint, config FailoverConfig) (*FailoverClient, error) {
+	if len(endpoints) == 0 {
+		return nil, ErrNoEndpoints
+	}
+
+	if config.HealthCheckInterval <= 0 {
+		config.HealthCheckInterval = 10 * time.Second
+	}
+	maxBlockLag := uint64(defaultMaxBlockLag)
+	if config.MaxBlockLag != nil {
+		maxBlockLag = *config.MaxBlockLag
+	}
+
+	fctx, cancel := context.WithCancel(context.Background())
+	c := &FailoverClient{config: config, maxBlockLag: maxBlockLag, ctx: fctx, cancel: cancel}
+	for _, e := range endpoints {
+		c.endpoints = append(c.endpoints, &endpoint{client: e.Client, status: EndpointStatus{Name: e.Name}})
+	}
+
+	c.CheckHealth(ctx)
+	go func() {
+		t := time.NewTicker(config.HealthCheckInterval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				c.CheckHealth(fctx)
+			case <-fctx.Done():
+				return
+			}
+		}
+	}()
+
+	return c, nil
+}
+
+// CheckHealth checks all the endpoints concurrently
+func (c *FailoverClient) CheckHealth(ctx context.Context) {
+	type result struct {
+		health  *Health
+		best    uint64
+		latency time.Duration
+		err     error
+	}
+
+	results := make([]result, len(c.endpoints))
+	var wg sync.WaitGroup
+	for i, e := range c.endpoints {
+		wg.Add(1)
+		go func(i int, e *endpoint) {
+			defer wg.Done()
+			start := time.Now()
+			h, err := NewSystemRPC(e.client).Health(ctx)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].latency = time.Since(start)
+
+			header, err := NewChainRPC(e.client).Header(ctx, nil)
+			if err != nil {
+				results[i].err = err
+				return
+			}
+			results[i].health = h
+			results[i].best = header.Number
+		}(i, e)
+	}
+	wg.Wait()
+
+	var best uint64
+	for _, r := range results {
+		if r.err == nil && r.best > best {
+			best = r.best
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, r := range results {
+		s := &c.endpoints[i].status
+		s.Err = r.err
+		if r.err != nil {
+			s.Healthy = false
+			continue
+		}
+
+		s.Peers = r.health.Peers
+		s.IsSyncing = r.health.IsSyncing
+		s.BestBlock = r.best
+		s.observe(r.latency)
+		s.Healthy = !r.health.IsSyncing &&
+			(!r.health.ShouldHavePeers || r.health.Peers >= c.config.MinPeers) &&
+			best-r.best <= c.maxBlockLag
+	}
+}
+
+// observe adds the duration to the moving average of the latency
+func (s *EndpointStatus) observe(d time.Duration) {
+	if s.Latency == 0 {
+		s.Latency = d
+		return
+	}
+	s.Latency = (s.Latency*4 + d) / 5
+}
+
+// Endpoints returns the status of the endpoints
+func (c *FailoverClient) Endpoints() []EndpointStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	res := make([]EndpointStatus, 0, len(c.endpoints))
+	for _, e := range c.endpoints {
+		res = append(res, e.status)
+	}
+	return res
+}
+
+// candidates returns the healthy endpoints in the order of the strategy followed by the unhealthy ones, which are
+// tried as a last resort. The round robin rotates over the healthy endpoints only so that they share the calls evenly.
+func (c *FailoverClient) candidates() []*endpoint {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var healthy, unhealthy []*endpoint
+	for _, e := range c.endpoints {
+		if e.status.Healthy {
+			healthy = append(healthy, e)
+		} else {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	if n := len(healthy); n > 0 {
+		start := c.next % n
+		healthy = append(append(make([]*endpoint, 0, n), healthy[start:]...), healthy[:start]...)
+		c.next = (start + 1) % n
+	}
+
+	if c.config.Strategy == LeastLatency {
+		sort.SliceStable(healthy, func(i, j int) bool {
+			return healthy[i].status.Latency < healthy[j].status.Latency
+		})
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// failsOver reports whether a request of the method may be sent again to another endpoint after a connection error,
+// the author_* requests may have reached the node
+func failsOver(method string) bool {
+	return !strings.HasPrefix(method, "author_")
+}
+
+// do runs the request on the candidates until one doesn't fail due to the connection, only the first candidate is
+// tried unless the request fails over
+func (c *FailoverClient) do(ctx context.Context, failover bool, request func(e *endpoint) error) error {
+	if c.ctx.Err() != nil {
+		return rpc.ErrClientQuit
+	}
+
+	err := ErrNoEndpoints
+	candidates := c.candidates()
+	if !failover && len(candidates) > 1 {
+		candidates = candidates[:1]
+	}
+	for _, e := range candidates {
+		start := time.Now()
+		err = request(e)
+		if !isConnectionError(err) || ctx.Err() != nil {
+			c.mu.Lock()
+			e.status.observe(time.Since(start))
+			c.mu.Unlock()
+			return err
+		}
+
+		c.mu.Lock()
+		e.status.Healthy = false
+		e.status.Err = err
+		c.mu.Unlock()
+	}
+
+	return err
+}
+
+func (c *FailoverClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *FailoverClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.do(ctx, failsOver(method), func(e *endpoint) error {
+		return e.client.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (c *FailoverClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+func (c *FailoverClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	failover := true
+	for _, e := range b {
+		failover = failover && failsOver(e.Method)
+	}
+
+	return c.do(ctx, failover, func(e *endpoint) error {
+		return e.client.BatchCallContext(ctx, b)
+	})
+}
+
+// Subscribe subscribes on the first endpoint that accepts the subscription, the subscription stays on that endpoint
+func (c *FailoverClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	var sub Subscription
+	err := c.do(ctx, failsOver(methods.Subscribe), func(e *endpoint) error {
+		var err error
+		sub, err = e.client.Subscribe(ctx, methods, channel, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+// Close stops the health checks and closes the endpoints
+func (c *FailoverClient) Close() {
+	c.cancel()
+	for _, e := range c.endpoints {
+		e.client.Close()
+	}
+}
@@ -0,0 +1,160 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testRPCError is an error returned by a node
+type testRPCError struct{}
+
+func (testRPCError) Error() string {
+	return "invalid params"
+}
+
+func (testRPCError) ErrorCode() int {
+	return -32602
+}
+
+func newTestNode(best uint64, peers uint64, syncing bool) *mockClient {
+	m := newMockClient()
+	m.handle("system_health", func(args ...interface{}) (interface{}, error) {
+		return Health{Peers: peers, IsSyncing: syncing, ShouldHavePeers: true}, nil
+	})
+	m.handle("chain_getHeader", func(args ...interface{}) (interface{}, error) {
+		h := newTestHeader(nil, 0)
+		h.Number = best
+		return testHeaderJSON(h), nil
+	})
+	m.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return "node", nil
+	})
+	return m
+}
+
+func TestFailoverClient_Health(t *testing.T) {
+	nodes := []*mockClient{newTestNode(10, 3, false), newTestNode(10, 3, true), newTestNode(5, 3, false), newTestNode(10, 0, false)}
+	var endpoints []Endpoint
+	for i, n := range nodes {
+		endpoints = append(endpoints, Endpoint{Name: string('a' + rune(i)), Client: n})
+	}
+
+	maxBlockLag := uint64(2)
+	c, err := NewFailoverClient(context.Background(), endpoints, FailoverConfig{MinPeers: 1, MaxBlockLag: &maxBlockLag})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var healthy []string
+	for _, s := range c.Endpoints() {
+		if s.Healthy {
+			healthy = append(healthy, s.Name)
+		}
+	}
+	assert.Equal(t, []string{"a"}, healthy)
+
+	// a few blocks behind the best endpoint by default
+	lagging, err := NewFailoverClient(context.Background(), []Endpoint{{"a", newTestNode(10, 3, false)},
+		{"b", newTestNode(7, 3, false)}, {"c", newTestNode(6, 3, false)}}, FailoverConfig{})
+	assert.NoError(t, err)
+	defer lagging.Close()
+	var statuses []bool
+	for _, s := range lagging.Endpoints() {
+		statuses = append(statuses, s.Healthy)
+	}
+	assert.Equal(t, []bool{true, true, false}, statuses)
+
+	// a strict lag
+	maxBlockLag = 0
+	strict, err := NewFailoverClient(context.Background(), []Endpoint{{"a", newTestNode(10, 3, false)},
+		{"b", newTestNode(9, 3, false)}}, FailoverConfig{MaxBlockLag: &maxBlockLag})
+	assert.NoError(t, err)
+	defer strict.Close()
+	assert.True(t, strict.Endpoints()[0].Healthy)
+	assert.False(t, strict.Endpoints()[1].Healthy)
+
+	var res string
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, c.Call(&res, "system_name"))
+	}
+	assert.Equal(t, 3, nodes[0].callCount("system_name"))
+}
+
+func TestFailoverClient_Failover(t *testing.T) {
+	a, b := newTestNode(10, 3, false), newTestNode(10, 3, false)
+	c, err := NewFailoverClient(context.Background(), []Endpoint{{"a", a}, {"b", b}}, FailoverConfig{HealthCheckInterval: time.Hour})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// round robin
+	var res string
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, c.Call(&res, "system_name"))
+	}
+	assert.Equal(t, 2, a.callCount("system_name"))
+	assert.Equal(t, 2, b.callCount("system_name"))
+
+	// the healthy endpoints share the calls evenly whatever the position of the unhealthy ones
+	nodes := []*mockClient{newTestNode(10, 3, false), newTestNode(10, 3, true), newTestNode(10, 3, false)}
+	rr, err := NewFailoverClient(context.Background(), []Endpoint{{"a", nodes[0]}, {"b", nodes[1]}, {"c", nodes[2]}},
+		FailoverConfig{HealthCheckInterval: time.Hour})
+	assert.NoError(t, err)
+	defer rr.Close()
+	for i := 0; i < 6; i++ {
+		assert.NoError(t, rr.Call(&res, "system_name"))
+	}
+	assert.Equal(t, []int{3, 0, 3}, []int{nodes[0].callCount("system_name"), nodes[1].callCount("system_name"),
+		nodes[2].callCount("system_name")})
+
+	// errors of the node are returned as is
+	a.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return nil, testRPCError{}
+	})
+	b.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return nil, testRPCError{}
+	})
+	assert.Equal(t, testRPCError{}, c.Call(&res, "system_name"))
+	assert.Equal(t, 5, a.callCount("system_name")+b.callCount("system_name"))
+
+	// connection errors fail over to the next endpoint
+	a.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return nil, errConnectionLost
+	})
+	b.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return "b", nil
+	})
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, c.Call(&res, "system_name"))
+		assert.Equal(t, "b", res)
+	}
+	assert.False(t, c.Endpoints()[0].Healthy)
+	assert.True(t, c.Endpoints()[1].Healthy)
+}
+
+func TestFailoverClient_Author(t *testing.T) {
+	a, b := newTestNode(10, 3, false), newTestNode(10, 3, false)
+	c, err := NewFailoverClient(context.Background(), []Endpoint{{"a", a}, {"b", b}}, FailoverConfig{HealthCheckInterval: time.Hour})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the extrinsic may have reached the node, it is not submitted to the next endpoint
+	for _, n := range []*mockClient{a, b} {
+		n.handle("author_submitExtrinsic", func(args ...interface{}) (interface{}, error) {
+			return nil, errConnectionLost
+		})
+	}
+	var res string
+	assert.Equal(t, errConnectionLost, c.Call(&res, "author_submitExtrinsic", "0x00"))
+	assert.Equal(t, 1, a.callCount("author_submitExtrinsic")+b.callCount("author_submitExtrinsic"))
+
+	// the other requests fail over
+	for _, n := range []*mockClient{a, b} {
+		n.handle("system_name", func(args ...interface{}) (interface{}, error) {
+			return nil, errConnectionLost
+		})
+	}
+	assert.Equal(t, errConnectionLost, c.Call(&res, "system_name"))
+	assert.Equal(t, 2, a.callCount("system_name")+b.callCount("system_name"))
+}
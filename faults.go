@@ -0,0 +1,414 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrInjectedDisconnect is the connection error of a Fault disconnecting the client, a net.Error as the read of a
+// connection reset by the node
+var ErrInjectedDisconnect error = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("injected disconnect")}
+
+// poolErrorMessages are the messages of the transaction pool errors
+var poolErrorMessages = map[int]string{
+	ErrCodeInvalidTransaction: "Invalid Transaction",
+	ErrCodeUnknownTransaction: "Unknown Transaction Validity",
+	ErrCodeTemporarilyBanned:  "Transaction is temporarily banned",
+	ErrCodeAlreadyImported:    "Transaction Already Imported",
+	ErrCodeTooLowPriority:     "Priority is too low",
+	ErrCodeCycleDetected:      "Cycle Detected",
+	ErrCodeImmediatelyDropped: "Immediately Dropped",
+}
+
+// Fault is injected in to a request by a FaultInjector, the faults are applied in the order of the fields
+type Fault struct {
+	// Latency delays the request
+	Latency time.Duration
+	// ErrorCode fails the request with the RPC error without sending it, eg: ErrCodeInvalidTransaction
+	ErrorCode int
+	// ErrorMessage of the RPC error, the message of the transaction pool error by default
+	ErrorMessage string
+	// Drop sends the request but drops the response, the request fails once its context is done
+	Drop bool
+	// Disconnect sends the request and fails it with ErrInjectedDisconnect. A subscription ends with
+	// ErrInjectedDisconnect after DisconnectAfter notifications.
+	Disconnect      bool
+	DisconnectAfter int
+	// MalformedHex corrupts the hex strings of the response or of the notifications
+	MalformedHex bool
+}
+
+// FaultRule injects the fault in to the requests of a method
+type FaultRule struct {
+	// Method is the RPC method, a prefix ending with "*" or "*" for all methods. The subscriptions are named
+	// after their subscribe method, eg: chain_subscribeNewHead.
+	Method string
+	// Skip is the number of requests of the method passed before the fault is injected
+	Skip int
+	// Count is the number of requests the fault is injected in to, 0 for all
+	Count int
+	// Probability to inject the fault in to a request, the fault is always injected if 0
+	Probability float64
+	Fault       Fault
+}
+
+func (r FaultRule) matches(method string) bool {
+	if strings.HasSuffix(r.Method, "*") {
+		return strings.HasPrefix(method, strings.TrimSuffix(r.Method, "*"))
+	}
+	return r.Method == method
+}
+
+type faultRule struct {
+	FaultRule
+	seen     int
+	injected int
+}
+
+// FaultInjector is a Client injecting faults in to the requests of the wrapped client for chaos testing. The first
+// rule matching a request applies, the random faults are reproducible with the same seed and order of requests.
+type FaultInjector struct {
+	client Client
+
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	rules []*faultRule
+}
+
+// NewFaultInjector injects the faults of the rules in to the requests of the client
+func NewFaultInjector(client Client, seed int64, rules ...FaultRule) *FaultInjector {
+	f := &FaultInjector{client: client, rnd: rand.New(rand.NewSource(seed))}
+	f.SetRules(rules...)
+	return f
+}
+
+// SetRules replaces the rules, eg: to script the faults of the next steps of a test
+func (f *FaultInjector) SetRules(rules ...FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = make([]*faultRule, len(rules))
+	for i, r := range rules {
+		f.rules[i] = &faultRule{FaultRule: r}
+	}
+}
+
+// fault returns the fault to inject in to the request of the method, nil if there is none
+func (f *FaultInjector) fault(method string) *Fault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, r := range f.rules {
+		if !r.matches(method) {
+			continue
+		}
+
+		r.seen++
+		if r.seen <= r.Skip || (r.Count > 0 && r.injected >= r.Count) {
+			continue
+		}
+		if r.Probability > 0 && f.rnd.Float64() >= r.Probability {
+			continue
+		}
+
+		r.injected++
+		fault := r.Fault
+		return &fault
+	}
+	return nil
+}
+
+// delay waits for the latency of the fault
+func delay(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(latency)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// faultError is an RPC error injected by a Fault
+type faultError struct {
+	code    int
+	message string
+}
+
+func (e *faultError) Error() string {
+	return e.message
+}
+
+func (e *faultError) ErrorCode() int {
+	return e.code
+}
+
+func (fault *Fault) error() error {
+	msg := fault.ErrorMessage
+	if msg == "" {
+		msg = poolErrorMessages[fault.ErrorCode]
+	}
+	if msg == "" {
+		msg = fmt.Sprintf("injected error %d", fault.ErrorCode)
+	}
+	return &faultError{code: fault.ErrorCode, message: msg}
+}
+
+// respond applies the faults to the response of a request sent to the node
+func (f *FaultInjector) respond(ctx context.Context, fault *Fault, raw json.RawMessage) (json.RawMessage, error) {
+	switch {
+	case fault.Drop:
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case fault.Disconnect:
+		return nil, ErrInjectedDisconnect
+	case fault.MalformedHex:
+		return f.corrupt(raw)
+	}
+	return raw, nil
+}
+
+// corrupt makes the hex strings of the JSON value invalid
+func (f *FaultInjector) corrupt(raw json.RawMessage) (json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mu.Lock()
+	v = corruptHex(v, f.rnd)
+	f.mu.Unlock()
+	return json.Marshal(v)
+}
+
+func corruptHex(v interface{}, rnd *rand.Rand) interface{} {
+	switch v := v.(type) {
+	case string:
+		if len(v) <= 2 || !strings.HasPrefix(v, "0x") {
+			return v
+		}
+		// an invalid digit or an odd length
+		if rnd.Intn(2) == 0 {
+			i := 2 + rnd.Intn(len(v)-2)
+			return v[:i] + "z" + v[i+1:]
+		}
+		return v[:len(v)-1]
+	case []interface{}:
+		for i := range v {
+			v[i] = corruptHex(v[i], rnd)
+		}
+	case map[string]interface{}:
+		// in a stable order to reproduce the corruption with the seed
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			v[k] = corruptHex(v[k], rnd)
+		}
+	}
+	return v
+}
+
+func (f *FaultInjector) Call(result interface{}, method string, args ...interface{}) error {
+	return f.CallContext(context.Background(), result, method, args...)
+}
+
+func (f *FaultInjector) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	fault := f.fault(method)
+	if fault == nil {
+		return f.client.CallContext(ctx, result, method, args...)
+	}
+
+	err := delay(ctx, fault.Latency)
+	if err != nil {
+		return err
+	}
+	if fault.ErrorCode != 0 {
+		return fault.error()
+	}
+
+	var raw json.RawMessage
+	err = f.client.CallContext(ctx, &raw, method, args...)
+	if err != nil {
+		return err
+	}
+
+	raw, err = f.respond(ctx, fault, raw)
+	if err != nil || result == nil {
+		return err
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func (f *FaultInjector) BatchCall(b []rpc.BatchElem) error {
+	return f.BatchCallContext(context.Background(), b)
+}
+
+// BatchCallContext injects the faults of the methods of the batch, the batch is delayed by the largest latency and
+// fails if the response of any request is dropped or disconnected
+func (f *FaultInjector) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	faults := make([]*Fault, len(b))
+	var latency time.Duration
+	for i, e := range b {
+		faults[i] = f.fault(e.Method)
+		if faults[i] != nil && faults[i].Latency > latency {
+			latency = faults[i].Latency
+		}
+	}
+
+	err := delay(ctx, latency)
+	if err != nil {
+		return err
+	}
+
+	var batch []rpc.BatchElem
+	var sent []int
+	raw := make([]json.RawMessage, len(b))
+	for i, e := range b {
+		if faults[i] != nil && faults[i].ErrorCode != 0 {
+			b[i].Error = faults[i].error()
+			continue
+		}
+
+		batch = append(batch, rpc.BatchElem{Method: e.Method, Args: e.Args, Result: &raw[i]})
+		sent = append(sent, i)
+	}
+
+	if len(batch) > 0 {
+		err = f.client.BatchCallContext(ctx, batch)
+		if err != nil {
+			return err
+		}
+	}
+
+	for j, i := range sent {
+		if batch[j].Error != nil {
+			b[i].Error = batch[j].Error
+			continue
+		}
+
+		res := raw[i]
+		if faults[i] != nil {
+			res, err = f.respond(ctx, faults[i], res)
+			if err != nil {
+				return err
+			}
+		}
+		if b[i].Result != nil {
+			b[i].Error = json.Unmarshal(res, b[i].Result)
+		}
+	}
+	return nil
+}
+
+// Subscribe injects the faults of the subscription, named after its subscribe method. The latency delays the
+// subscribe request, a dropped response fails the subscribe request once the context is done.
+func (f *FaultInjector) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	fault := f.fault(methods.Subscribe)
+	if fault == nil {
+		return f.client.Subscribe(ctx, methods, channel, args...)
+	}
+
+	err := delay(ctx, fault.Latency)
+	if err != nil {
+		return nil, err
+	}
+	if fault.ErrorCode != 0 {
+		return nil, fault.error()
+	}
+
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("invalid channel %T", channel)
+	}
+
+	raw := make(chan json.RawMessage)
+	sub, err := f.client.Subscribe(ctx, methods, raw, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if fault.Drop {
+		sub.Unsubscribe()
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+
+	disconnect := make(chan struct{})
+	if fault.Disconnect {
+		sub = disconnectingSubscription(sub, disconnect)
+		if fault.DisconnectAfter == 0 {
+			close(disconnect)
+		}
+	}
+
+	fs := &forwardingSubscription{err: make(chan error, 1), quit: make(chan struct{})}
+	notifications := 0
+	go fs.forward(sub, raw, ch, func(n json.RawMessage) (json.RawMessage, error) {
+		notifications++
+		if fault.Disconnect {
+			if notifications > fault.DisconnectAfter {
+				return nil, ErrInjectedDisconnect
+			}
+			if notifications == fault.DisconnectAfter {
+				close(disconnect)
+			}
+		}
+		if fault.MalformedHex {
+			return f.corrupt(n)
+		}
+		return n, nil
+	}, func(error) {})
+	return fs, nil
+}
+
+// disconnectingSubscription ends the subscription with ErrInjectedDisconnect once disconnect is closed
+func disconnectingSubscription(sub Subscription, disconnect <-chan struct{}) Subscription {
+	ds := &injectedSubscription{Subscription: sub, err: make(chan error, 1)}
+	go func() {
+		defer close(ds.err)
+		select {
+		case err, ok := <-sub.Err():
+			if ok {
+				ds.err <- err
+			}
+		case <-disconnect:
+			sub.Unsubscribe()
+			ds.err <- ErrInjectedDisconnect
+		}
+	}()
+	return ds
+}
+
+type injectedSubscription struct {
+	Subscription
+	err chan error
+}
+
+func (s *injectedSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (f *FaultInjector) Close() {
+	f.client.Close()
+}
@@ -0,0 +1,99 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func newFaultyNode(t *testing.T, rules ...FaultRule) (*substratetest.Node, *FaultInjector) {
+	n := substratetest.NewNode()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	return n, NewFaultInjector(c, 1, rules...)
+}
+
+func TestFaultInjector_SubmitExtrinsic(t *testing.T) {
+	n, f := newFaultyNode(t, FaultRule{Method: "author_*", Count: 1, Fault: Fault{ErrorCode: ErrCodeTooLowPriority}})
+	defer n.Close()
+	defer f.Close()
+
+	a := newTestAuthor(t, f)
+	a1, _ := testAnchorCall()
+	_, err := a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assertPoolError(t, ErrCodeTooLowPriority, err)
+	assert.EqualError(t, err, "Priority is too low")
+	assert.Empty(t, n.Pending())
+
+	// the response is lost but the extrinsic reaches the pool
+	f.SetRules(FaultRule{Method: "author_submitExtrinsic", Fault: Fault{Latency: 10 * time.Millisecond, Drop: true}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	assert.Len(t, n.Pending(), 1)
+
+	f.SetRules(FaultRule{Method: "author_submitExtrinsic", Fault: Fault{Disconnect: true}})
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.Equal(t, ErrInjectedDisconnect, err)
+	assert.True(t, isConnectionError(err))
+	assert.Len(t, n.Pending(), 2)
+}
+
+func TestFaultInjector_MalformedHex(t *testing.T) {
+	n, f := newFaultyNode(t, FaultRule{Method: "chain_getHeader", Skip: 1, Fault: Fault{MalformedHex: true}},
+		FaultRule{Method: "state_getStorage", Fault: Fault{MalformedHex: true}})
+	defer n.Close()
+	defer f.Close()
+
+	chain := NewChainRPC(f)
+	_, err := chain.Header(context.Background(), nil)
+	assert.NoError(t, err)
+	_, err = chain.Header(context.Background(), nil)
+	assert.Error(t, err)
+
+	n.SetStorage([]byte{1}, []byte{1, 2})
+	n.ProduceBlock()
+	_, err = NewStateRPC(f).StorageMulti(context.Background(), [][]byte{{1}}, nil)
+	assert.Error(t, err)
+}
+
+func TestFaultInjector_Subscription(t *testing.T) {
+	n, f := newFaultyNode(t, FaultRule{Method: "chain_subscribeNewHead", Fault: Fault{Disconnect: true, DisconnectAfter: 1}})
+	defer n.Close()
+	defer f.Close()
+
+	heads := make(chan Header)
+	sub, err := f.Subscribe(context.Background(), NewHeadSubscription, heads)
+	assert.NoError(t, err)
+	n.ProduceBlock()
+	assert.Equal(t, uint64(1), (<-heads).Number)
+	assert.Equal(t, ErrInjectedDisconnect, <-sub.Err())
+
+	f.SetRules(FaultRule{Method: "chain_*", Fault: Fault{Disconnect: true}})
+	sub, err = f.Subscribe(context.Background(), FinalizedHeadSubscription, heads)
+	assert.NoError(t, err)
+	assert.Equal(t, ErrInjectedDisconnect, <-sub.Err())
+}
+
+func TestFaultInjector_Seed(t *testing.T) {
+	rule := FaultRule{Method: "*", Probability: 0.5, Fault: Fault{ErrorCode: ErrCodeTemporarilyBanned}}
+	injected := func(seed int64) []bool {
+		f := NewFaultInjector(nil, seed, rule)
+		var res []bool
+		for i := 0; i < 32; i++ {
+			res = append(res, f.fault("chain_getHeader") != nil)
+		}
+		return res
+	}
+
+	assert.Equal(t, injected(42), injected(42))
+	assert.NotEqual(t, injected(42), injected(43))
+	assert.Contains(t, injected(42), true)
+	assert.Contains(t, injected(42), false)
+}
@@ -0,0 +1,239 @@
+package substrate
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// FollowEventType of the events emitted by the Follower
+type FollowEventType uint8
+
+const (
+	// NewBlock is emitted when a block becomes part of the canonical chain
+	NewBlock FollowEventType = iota
+	// Retracted is emitted when a block is no longer part of the canonical chain due to a reorg
+	Retracted
+	// Finalized is emitted when a canonical block is finalized
+	Finalized
+)
+
+func (t FollowEventType) String() string {
+	switch t {
+	case NewBlock:
+		return "NewBlock"
+	case Retracted:
+		return "Retracted"
+	case Finalized:
+		return "Finalized"
+	default:
+		return fmt.Sprintf("FollowEventType(%d)", t)
+	}
+}
+
+// FollowEvent is a change of the canonical chain
+type FollowEvent struct {
+	Type   FollowEventType
+	Hash   Hash
+	Header *Header
+}
+
+// Follower tracks the canonical chain from the new and finalized heads using the parent hashes.
+// Blocks of retracted forks are reported so that consumers can undo their work.
+type Follower struct {
+	chain *Chain
+
+	mu sync.Mutex
+	// headers of the unfinalized blocks by hash, including the ones of forks
+	headers map[string]*Header
+	// canonical hashes by block number from the base
+	canonical map[uint64]string
+	// base is the lowest tracked block number, either the first head or the last finalized block
+	base    uint64
+	best    *Header
+	started bool
+	// finalized is set once the base is a finalized block
+	finalized bool
+	// finalize is the number of the next block to be finalized
+	finalize uint64
+}
+
+func NewFollower(chain *Chain) *Follower {
+	return &Follower{chain: chain, headers: make(map[string]*Header), canonical: make(map[uint64]string)}
+}
+
+// Follow subscribes to the new and finalized heads and sends the events to the given channel until the
+// context is cancelled or a subscription fails
+func (f *Follower) Follow(ctx context.Context, events chan<- FollowEvent) error {
+	heads := make(chan *Header, 16)
+	sub, err := f.chain.client.Subscribe(ctx, NewHeadSubscription, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	finalizedHeads := make(chan *Header, 16)
+	fsub, err := f.chain.client.Subscribe(ctx, FinalizedHeadSubscription, finalizedHeads)
+	if err != nil {
+		return err
+	}
+	defer fsub.Unsubscribe()
+
+	for {
+		var evs []FollowEvent
+		select {
+		case h := <-heads:
+			evs, err = f.ImportHead(ctx, h)
+		case h := <-finalizedHeads:
+			evs, err = f.ImportFinalized(ctx, h)
+		case err = <-sub.Err():
+			return err
+		case err = <-fsub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err != nil {
+			return err
+		}
+
+		for _, e := range evs {
+			select {
+			case events <- e:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+}
+
+// Best returns the head of the canonical chain
+func (f *Follower) Best() *Header {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.best
+}
+
+// ImportHead makes the given header the head of the canonical chain and returns the resulting events.
+// Unknown ancestors are fetched with chain_getHeader.
+func (f *Follower) ImportHead(ctx context.Context, h *Header) ([]FollowEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.importHead(ctx, h)
+}
+
+func (f *Follower) importHead(ctx context.Context, h *Header) ([]FollowEvent, error) {
+	hash := h.Hash()
+	if !f.started {
+		f.started = true
+		f.base = h.Number
+		f.finalize = h.Number
+		f.headers[string(hash)] = h
+		f.canonical[h.Number] = string(hash)
+		f.best = h
+		return []FollowEvent{{Type: NewBlock, Hash: hash, Header: h}}, nil
+	}
+
+	if f.canonical[h.Number] == string(hash) {
+		return nil, nil
+	}
+
+	if f.finalized && h.Number <= f.base {
+		return nil, fmt.Errorf("block %d is not above the last finalized block %d", h.Number, f.base)
+	}
+
+	// walk back the new branch until a canonical ancestor or the lowest tracked block
+	f.headers[string(hash)] = h
+	branch := []*Header{h}
+	cur := h
+	for cur.Number > f.base && f.canonical[cur.Number-1] != string(cur.ParentHash) {
+		if f.finalized && cur.Number-1 == f.base {
+			return nil, fmt.Errorf("block %s forks below the last finalized block %d", hash.String(), f.base)
+		}
+
+		p, ok := f.headers[string(cur.ParentHash)]
+		if !ok {
+			var err error
+			p, err = f.chain.Header(ctx, cur.ParentHash)
+			if err != nil {
+				return nil, err
+			}
+			f.headers[string(cur.ParentHash)] = p
+		}
+		branch = append(branch, p)
+		cur = p
+	}
+
+	// retract the old branch from the head
+	var evs []FollowEvent
+	for n := f.best.Number + 1; n > cur.Number; n-- {
+		r, ok := f.canonical[n-1]
+		if !ok {
+			continue
+		}
+		evs = append(evs, FollowEvent{Type: Retracted, Hash: Hash(r), Header: f.headers[r]})
+		delete(f.canonical, n-1)
+	}
+
+	// apply the new branch from the ancestor
+	for i := len(branch) - 1; i >= 0; i-- {
+		b := branch[i]
+		bh := b.Hash()
+		f.canonical[b.Number] = string(bh)
+		evs = append(evs, FollowEvent{Type: NewBlock, Hash: bh, Header: b})
+	}
+
+	if cur.Number < f.base {
+		f.base = cur.Number
+	}
+	f.best = h
+	return evs, nil
+}
+
+// ImportFinalized finalizes the canonical chain up to the given header and returns the resulting events.
+// If the header is not canonical the chain is reorganised first. Finalized blocks older than the tracked
+// blocks are ignored.
+func (f *Follower) ImportFinalized(ctx context.Context, h *Header) ([]FollowEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.started && h.Number < f.base {
+		return nil, nil
+	}
+
+	hash := h.Hash()
+	var evs []FollowEvent
+	if f.canonical[h.Number] != string(hash) {
+		var err error
+		evs, err = f.importHead(ctx, h)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for n := f.finalize; n <= h.Number; n++ {
+		c, ok := f.canonical[n]
+		if !ok {
+			continue
+		}
+		evs = append(evs, FollowEvent{Type: Finalized, Hash: Hash(c), Header: f.headers[c]})
+	}
+
+	if h.Number >= f.finalize {
+		f.finalize = h.Number + 1
+	}
+
+	// forget the finalized blocks and the forks below the finalized block
+	for n := f.base; n < h.Number; n++ {
+		delete(f.canonical, n)
+	}
+	for k, hd := range f.headers {
+		if hd.Number < h.Number {
+			delete(f.headers, k)
+		}
+	}
+	f.base = h.Number
+	f.finalized = true
+	return evs, nil
+}
@@ -0,0 +1,134 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHeader(parent *Header, fork byte) *Header {
+	h := &Header{StateRoot: make(Hash, 32), ExtrinsicsRoot: make(Hash, 32)}
+	h.StateRoot[0] = fork
+	if parent != nil {
+		h.ParentHash = parent.Hash()
+		h.Number = parent.Number + 1
+	} else {
+		h.ParentHash = make(Hash, 32)
+	}
+	return h
+}
+
+func testHeaderJSON(h *Header) map[string]interface{} {
+	return map[string]interface{}{
+		"parentHash":     hexutil.Encode(h.ParentHash),
+		"number":         hexutil.EncodeUint64(h.Number),
+		"stateRoot":      hexutil.Encode(h.StateRoot),
+		"extrinsicsRoot": hexutil.Encode(h.ExtrinsicsRoot),
+		"digest":         map[string]interface{}{"logs": []string{}},
+	}
+}
+
+// testChain creates count blocks on top of the parent, the blocks are served with chain_getHeader
+func testChain(m *mockClient, headers map[string]*Header, parent *Header, count int, fork byte) []*Header {
+	var res []*Header
+	for i := 0; i < count; i++ {
+		h := newTestHeader(parent, fork)
+		headers[hexutil.Encode(h.Hash())] = h
+		res = append(res, h)
+		parent = h
+	}
+
+	m.handle("chain_getHeader", func(args ...interface{}) (interface{}, error) {
+		return testHeaderJSON(headers[args[0].(string)]), nil
+	})
+	return res
+}
+
+func assertFollowEvents(t *testing.T, evs []FollowEvent, types []FollowEventType, headers []*Header) {
+	assert.Len(t, evs, len(types))
+	for i := range evs {
+		assert.Equal(t, types[i], evs[i].Type)
+		assert.Equal(t, headers[i].Hash(), evs[i].Hash)
+		assert.Equal(t, headers[i].Number, evs[i].Header.Number)
+	}
+}
+
+func TestHeader_Hash(t *testing.T) {
+	h := &Header{}
+	err := h.UnmarshalJSON([]byte(`{"parentHash":"0x0000000000000000000000000000000000000000000000000000000000000000","number":"0x0","stateRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","extrinsicsRoot":"0x0000000000000000000000000000000000000000000000000000000000000000","digest":{"logs":["0x0001"]}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{{0, 1}}, h.Digest)
+	assert.Len(t, h.Hash(), 32)
+	assert.NotEqual(t, (&Header{ParentHash: h.ParentHash, StateRoot: h.StateRoot, ExtrinsicsRoot: h.ExtrinsicsRoot}).Hash(), h.Hash())
+}
+
+func TestFollower_Reorg(t *testing.T) {
+	m := newMockClient()
+	headers := map[string]*Header{}
+	a := testChain(m, headers, nil, 4, 0)
+	f := NewFollower(NewChainRPC(m))
+
+	evs, err := f.ImportHead(context.Background(), a[0])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{NewBlock}, a[:1])
+
+	// missing heads are fetched
+	evs, err = f.ImportHead(context.Background(), a[2])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{NewBlock, NewBlock}, a[1:3])
+
+	// already canonical
+	evs, err = f.ImportHead(context.Background(), a[1])
+	assert.NoError(t, err)
+	assert.Len(t, evs, 0)
+
+	// fork from block 1 with a longer chain
+	b := testChain(m, headers, a[1], 3, 1)
+	evs, err = f.ImportHead(context.Background(), b[2])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{Retracted, NewBlock, NewBlock, NewBlock}, []*Header{a[2], b[0], b[1], b[2]})
+	assert.Equal(t, b[2], f.Best())
+
+	// back to the original chain
+	evs, err = f.ImportHead(context.Background(), a[3])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{Retracted, Retracted, Retracted, NewBlock, NewBlock},
+		[]*Header{b[2], b[1], b[0], a[2], a[3]})
+}
+
+func TestFollower_Finalized(t *testing.T) {
+	m := newMockClient()
+	headers := map[string]*Header{}
+	a := testChain(m, headers, nil, 5, 0)
+	f := NewFollower(NewChainRPC(m))
+
+	_, err := f.ImportHead(context.Background(), a[2])
+	assert.NoError(t, err)
+	_, err = f.ImportHead(context.Background(), a[3])
+	assert.NoError(t, err)
+
+	// older than the tracked blocks
+	evs, err := f.ImportFinalized(context.Background(), a[1])
+	assert.NoError(t, err)
+	assert.Len(t, evs, 0)
+
+	evs, err = f.ImportFinalized(context.Background(), a[3])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{Finalized, Finalized}, a[2:4])
+
+	// forks below the finalized block are rejected
+	b := testChain(m, headers, a[2], 2, 1)
+	_, err = f.ImportHead(context.Background(), b[1])
+	assert.Error(t, err)
+
+	// finalizing a fork reorganises the chain
+	_, err = f.ImportHead(context.Background(), a[4])
+	assert.NoError(t, err)
+	c := testChain(m, headers, a[3], 2, 2)
+	evs, err = f.ImportFinalized(context.Background(), c[1])
+	assert.NoError(t, err)
+	assertFollowEvents(t, evs, []FollowEventType{Retracted, NewBlock, NewBlock, Finalized, Finalized},
+		[]*Header{a[4], c[0], c[1], c[0], c[1]})
+}
@@ -0,0 +1,318 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/ed25519"
+)
+
+// precommit is the index of Precommit in the grandpa Message enum
+const precommit = 1
+
+// Grandpa exposes the grandpa_* RPCs
+type Grandpa struct {
+	client Client
+}
+
+func NewGrandpaRPC(client Client) *Grandpa {
+	return &Grandpa{client: client}
+}
+
+// RoundVotes are the prevotes or precommits of a round
+type RoundVotes struct {
+	CurrentWeight uint32   `json:"currentWeight"`
+	Missing       []string `json:"missing"`
+}
+
+// RoundState of a grandpa round
+type RoundState struct {
+	Round           uint32     `json:"round"`
+	TotalWeight     uint32     `json:"totalWeight"`
+	ThresholdWeight uint32     `json:"thresholdWeight"`
+	Prevotes        RoundVotes `json:"prevotes"`
+	Precommits      RoundVotes `json:"precommits"`
+}
+
+// ReportedRoundStates is the result of grandpa_roundState
+type ReportedRoundStates struct {
+	SetID      uint32       `json:"setId"`
+	Best       RoundState   `json:"best"`
+	Background []RoundState `json:"background"`
+}
+
+// RoundState grandpa_roundState
+func (g *Grandpa) RoundState(ctx context.Context) (*ReportedRoundStates, error) {
+	var res ReportedRoundStates
+	err := g.client.CallContext(ctx, &res, "grandpa_roundState")
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// ProveFinality grandpa_proveFinality, returns the finality proof of the blocks begin..end for the given authority
+// set or nil if the node can't prove the finality
+func (g *Grandpa) ProveFinality(ctx context.Context, begin, end Hash, setID uint64) ([]FinalityProofFragment, error) {
+	var res *string
+	err := g.client.CallContext(ctx, &res, "grandpa_proveFinality", begin.String(), end.String(), setID)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, nil
+	}
+
+	b, err := hexutil.Decode(*res)
+	if err != nil {
+		return nil, err
+	}
+
+	return DecodeFinalityProof(b)
+}
+
+// SubscribeJustifications grandpa_subscribeJustifications, sends the justifications of the finalized blocks
+// until the context is done or the subscription is unsubscribed
+func (g *Grandpa) SubscribeJustifications(ctx context.Context, ch chan<- *GrandpaJustification) (Subscription, error) {
+	return g.client.Subscribe(ctx, JustificationSubscription, ch)
+}
+
+// GrandpaAuthority is a member of an authority set
+type GrandpaAuthority struct {
+	ID     [32]byte
+	Weight uint64
+}
+
+// Precommit is a vote for a block and its ancestors
+type Precommit struct {
+	TargetHash Hash
+	// TargetNumber is encoded as the BlockNumber of the runtime, u64 as in the TypeRegistry
+	TargetNumber uint64
+}
+
+func (p *Precommit) ParityDecode(decoder scalecodec.Decoder) {
+	p.TargetHash = make(Hash, 32)
+	decoder.Read(p.TargetHash)
+	decoder.Decode(&p.TargetNumber)
+}
+
+func (p Precommit) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(p.TargetHash)
+	encoder.Encode(p.TargetNumber)
+}
+
+// SignedPrecommit is a precommit signed by an authority
+type SignedPrecommit struct {
+	Precommit Precommit
+	Signature [64]byte
+	ID        [32]byte
+}
+
+func (s *SignedPrecommit) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&s.Precommit)
+	decoder.Read(s.Signature[:])
+	decoder.Read(s.ID[:])
+}
+
+func (s SignedPrecommit) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Encode(s.Precommit)
+	encoder.Write(s.Signature[:])
+	encoder.Write(s.ID[:])
+}
+
+// Commit is the set of precommits finalizing the target block
+type Commit struct {
+	TargetHash Hash
+	// TargetNumber is encoded as the BlockNumber of the runtime, u64 as in the TypeRegistry
+	TargetNumber uint64
+	Precommits   []SignedPrecommit
+}
+
+func (c *Commit) ParityDecode(decoder scalecodec.Decoder) {
+	c.TargetHash = make(Hash, 32)
+	decoder.Read(c.TargetHash)
+	decoder.Decode(&c.TargetNumber)
+	decoder.Decode(&c.Precommits)
+}
+
+func (c Commit) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(c.TargetHash)
+	encoder.Encode(c.TargetNumber)
+	encoder.Encode(c.Precommits)
+}
+
+// GrandpaJustification proves the finality of the commit target
+type GrandpaJustification struct {
+	Round  uint64
+	Commit Commit
+	// VotesAncestries are the headers between the commit target and the precommit targets
+	VotesAncestries []Header
+}
+
+func (j *GrandpaJustification) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&j.Round)
+	decoder.Decode(&j.Commit)
+	decoder.Decode(&j.VotesAncestries)
+}
+
+func (j GrandpaJustification) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Encode(j.Round)
+	encoder.Encode(j.Commit)
+	encoder.Encode(j.VotesAncestries)
+}
+
+// UnmarshalJSON decodes the hex encoded justification sent by grandpa_subscribeJustifications
+func (j *GrandpaJustification) UnmarshalJSON(b []byte) error {
+	var s string
+	err := json.Unmarshal(b, &s)
+	if err != nil {
+		return err
+	}
+
+	d, err := hexutil.Decode(s)
+	if err != nil {
+		return err
+	}
+
+	dj, err := DecodeGrandpaJustification(d)
+	if err != nil {
+		return err
+	}
+
+	*j = *dj
+	return nil
+}
+
+// DecodeGrandpaJustification decodes an encoded justification, eg: SignedBlock.Justification
+func DecodeGrandpaJustification(b []byte) (j *GrandpaJustification, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode justification: %v", rec)
+		}
+	}()
+
+	j = &GrandpaJustification{}
+	scalecodec.NewDecoder(bytes.NewReader(b)).Decode(j)
+	return j, nil
+}
+
+// Verify checks the signatures of the precommits against the authority set and that the precommits reach the
+// threshold weight of the set
+func (j *GrandpaJustification) Verify(setID uint64, authorities []GrandpaAuthority) error {
+	weights := make(map[[32]byte]uint64, len(authorities))
+	var total uint64
+	for _, a := range authorities {
+		weights[a.ID] = a.Weight
+		total += a.Weight
+	}
+
+	if total == 0 {
+		return errors.New("empty authority set")
+	}
+
+	ancestries := make(map[string]*Header, len(j.VotesAncestries))
+	for i := range j.VotesAncestries {
+		h := &j.VotesAncestries[i]
+		ancestries[string(h.Hash())] = h
+	}
+
+	voted := make(map[[32]byte]bool)
+	var weight uint64
+	for _, p := range j.Commit.Precommits {
+		w, ok := weights[p.ID]
+		if !ok {
+			return fmt.Errorf("precommit by %s is not from the authority set", hexutil.Encode(p.ID[:]))
+		}
+
+		if !ed25519.Verify(p.ID[:], precommitMessage(p.Precommit, j.Round, setID), p.Signature[:]) {
+			return fmt.Errorf("invalid precommit signature by %s", hexutil.Encode(p.ID[:]))
+		}
+
+		if !j.descendsFromTarget(p.Precommit.TargetHash, ancestries) {
+			return fmt.Errorf("precommit target %s is not a descendant of the commit target", p.Precommit.TargetHash.String())
+		}
+
+		// equivocations count once
+		if voted[p.ID] {
+			continue
+		}
+		voted[p.ID] = true
+		weight += w
+	}
+
+	threshold := total - (total-1)/3
+	if weight < threshold {
+		return fmt.Errorf("precommit weight %d is below the threshold %d", weight, threshold)
+	}
+
+	return nil
+}
+
+// descendsFromTarget walks the ancestries from the given block until the commit target
+func (j *GrandpaJustification) descendsFromTarget(hash Hash, ancestries map[string]*Header) bool {
+	for {
+		if bytes.Equal(hash, j.Commit.TargetHash) {
+			return true
+		}
+
+		h, ok := ancestries[string(hash)]
+		if !ok {
+			return false
+		}
+		hash = h.ParentHash
+	}
+}
+
+// precommitMessage is the signed payload of a precommit, (Message::Precommit, round, set ID)
+func precommitMessage(p Precommit, round, setID uint64) []byte {
+	var bb bytes.Buffer
+	encoder := scalecodec.NewEncoder(&bb)
+	encoder.PushByte(precommit)
+	encoder.Encode(p)
+	encoder.Encode(round)
+	encoder.Encode(setID)
+	return bb.Bytes()
+}
+
+// FinalityProofFragment is a part of the proof returned by grandpa_proveFinality
+type FinalityProofFragment struct {
+	Block Hash
+	// Justification is the encoded GrandpaJustification
+	Justification  []byte
+	UnknownHeaders []Header
+	// AuthoritiesProof is the storage proof of the authority set change, if any
+	HasAuthoritiesProof bool
+	AuthoritiesProof    [][]byte
+}
+
+func (f *FinalityProofFragment) ParityDecode(decoder scalecodec.Decoder) {
+	f.Block = make(Hash, 32)
+	decoder.Read(f.Block)
+	decoder.Decode(&f.Justification)
+	decoder.Decode(&f.UnknownHeaders)
+	decoder.DecodeOption(&f.HasAuthoritiesProof, &f.AuthoritiesProof)
+}
+
+// GrandpaJustification decodes the justification of the fragment
+func (f *FinalityProofFragment) GrandpaJustification() (*GrandpaJustification, error) {
+	return DecodeGrandpaJustification(f.Justification)
+}
+
+// DecodeFinalityProof decodes the fragments of an encoded finality proof
+func DecodeFinalityProof(b []byte) (fragments []FinalityProofFragment, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode finality proof: %v", rec)
+		}
+	}()
+
+	scalecodec.NewDecoder(bytes.NewReader(b)).Decode(&fragments)
+	return fragments, nil
+}
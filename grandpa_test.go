@@ -0,0 +1,138 @@
+package substrate
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/ed25519"
+)
+
+type testVoter struct {
+	pub  ed25519.PublicKey
+	priv ed25519.PrivateKey
+}
+
+func newTestVoters(t *testing.T, n int) ([]testVoter, []GrandpaAuthority) {
+	var voters []testVoter
+	var authorities []GrandpaAuthority
+	for i := 0; i < n; i++ {
+		pub, priv, err := ed25519.GenerateKey(rand.Reader)
+		assert.NoError(t, err)
+		voters = append(voters, testVoter{pub, priv})
+		a := GrandpaAuthority{Weight: 1}
+		copy(a.ID[:], pub)
+		authorities = append(authorities, a)
+	}
+	return voters, authorities
+}
+
+func signTestPrecommit(v testVoter, p Precommit, round, setID uint64) SignedPrecommit {
+	sp := SignedPrecommit{Precommit: p}
+	copy(sp.Signature[:], ed25519.Sign(v.priv, precommitMessage(p, round, setID)))
+	copy(sp.ID[:], v.pub)
+	return sp
+}
+
+func TestGrandpaJustification_Verify(t *testing.T) {
+	voters, authorities := newTestVoters(t, 4)
+	target := newTestHeader(nil, 0)
+	child := newTestHeader(target, 0)
+	j := GrandpaJustification{
+		Round: 2,
+		Commit: Commit{
+			TargetHash:   target.Hash(),
+			TargetNumber: 0,
+			Precommits: []SignedPrecommit{
+				signTestPrecommit(voters[0], Precommit{target.Hash(), 0}, 2, 1),
+				signTestPrecommit(voters[1], Precommit{target.Hash(), 0}, 2, 1),
+				// a vote for a descendant of the target
+				signTestPrecommit(voters[2], Precommit{child.Hash(), 1}, 2, 1),
+			},
+		},
+		VotesAncestries: []Header{*child},
+	}
+
+	// encoding round trip
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(j)
+	dj, err := DecodeGrandpaJustification(bb.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, j.Round, dj.Round)
+	assert.Len(t, dj.Commit.Precommits, 3)
+	assert.Equal(t, child.Hash(), dj.VotesAncestries[0].Hash())
+
+	assert.NoError(t, dj.Verify(1, authorities))
+
+	// wrong set ID
+	assert.Error(t, dj.Verify(2, authorities))
+
+	// not from the set
+	assert.Error(t, dj.Verify(1, authorities[1:]))
+
+	// missing ancestry
+	dj.VotesAncestries = nil
+	assert.Error(t, dj.Verify(1, authorities))
+
+	// below the threshold
+	dj.VotesAncestries = j.VotesAncestries
+	dj.Commit.Precommits = append(dj.Commit.Precommits[:2], dj.Commit.Precommits[0])
+	assert.Error(t, dj.Verify(1, authorities))
+}
+
+// devJustification is a justification for block 6 in round 3 as a --dev chain sends it, its authority set 0 is
+// //Alice's ed25519 key. It is encoded byte by byte rather than with this package: round, commit target, the
+// precommits with their signature of (Message::Precommit, round, set ID) and no votes ancestries, the block
+// numbers are u64.
+const devJustification = "0x030000000000000031d6b85d9d3a07d0668f6f1a2c8ad5023ed478e9010c52be700e9459b9cdb302060000000000" +
+	"00000431d6b85d9d3a07d0668f6f1a2c8ad5023ed478e9010c52be700e9459b9cdb3020600000000000000c3d1cd14edbcc5f338762f2f6fd8" +
+	"710133dee657602077e3153a3fd3b31934473de80b61461a07bc0cc50a9ca5da29cc3e35227f37944f7988971e838e35660988dc3417d5058ec4" +
+	"b4503e0c12ea1a0a89be200fe98922423d4334014fa6b0ee00"
+
+func TestGrandpaJustification_Dev(t *testing.T) {
+	j, err := DecodeGrandpaJustification(hexutil.MustDecode(devJustification))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), j.Round)
+	assert.Equal(t, "0x31d6b85d9d3a07d0668f6f1a2c8ad5023ed478e9010c52be700e9459b9cdb302", j.Commit.TargetHash.String())
+	assert.Equal(t, uint64(6), j.Commit.TargetNumber)
+	if assert.Len(t, j.Commit.Precommits, 1) {
+		assert.Equal(t, uint64(6), j.Commit.Precommits[0].Precommit.TargetNumber)
+	}
+	assert.Empty(t, j.VotesAncestries)
+
+	alice := GrandpaAuthority{Weight: 1}
+	copy(alice.ID[:], hexutil.MustDecode("0x88dc3417d5058ec4b4503e0c12ea1a0a89be200fe98922423d4334014fa6b0ee"))
+	assert.NoError(t, j.Verify(0, []GrandpaAuthority{alice}))
+	assert.Error(t, j.Verify(1, []GrandpaAuthority{alice}))
+
+	// re-encoded as decoded
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(j)
+	assert.Equal(t, devJustification, hexutil.Encode(bb.Bytes()))
+}
+
+func TestGrandpaJustification_UnmarshalJSON(t *testing.T) {
+	j := GrandpaJustification{Commit: Commit{TargetHash: make(Hash, 32)}}
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(j)
+
+	dj := &GrandpaJustification{}
+	assert.NoError(t, dj.UnmarshalJSON([]byte(`"`+hexutil.Encode(bb.Bytes())+`"`)))
+	assert.Equal(t, j.Commit.TargetHash, dj.Commit.TargetHash)
+	assert.Error(t, dj.UnmarshalJSON([]byte(`"0x01"`)))
+}
+
+func TestHeader_ParityDecode(t *testing.T) {
+	h := newTestHeader(nil, 1)
+	h.Digest = [][]byte{{0, 4, 1}, append([]byte{5, 'a', 'u', 'r', 'a', 4}, 2), {8}}
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(h)
+
+	dh := Header{}
+	scalecodec.NewDecoder(bytes.NewReader(bb.Bytes())).Decode(&dh)
+	assert.Equal(t, *h, dh)
+	assert.Equal(t, h.Hash(), dh.Hash())
+}
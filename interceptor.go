@@ -0,0 +1,256 @@
+package substrate
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// InvocationKind is the kind of request seen by an Interceptor
+type InvocationKind uint8
+
+const (
+	CallInvocation InvocationKind = iota
+	BatchInvocation
+	SubscribeInvocation
+)
+
+// Invocation is a request of a Client
+type Invocation struct {
+	Kind InvocationKind
+	// Method is the RPC method, "batch" for batches and the subscribe method for subscriptions
+	Method string
+	Args   []interface{}
+	// Batch is set for batches
+	Batch []rpc.BatchElem
+}
+
+// Invoker performs the request
+type Invoker func(ctx context.Context) error
+
+// Interceptor wraps the requests of a Client, the request is performed by calling next. An interceptor can
+// inspect the invocation, time the request, observe its error or call next again.
+type Interceptor func(ctx context.Context, inv *Invocation, next Invoker) error
+
+type interceptedClient struct {
+	client       Client
+	interceptors []Interceptor
+}
+
+// WithInterceptors wraps the client with the interceptors, the first interceptor is the outermost one
+func WithInterceptors(client Client, interceptors ...Interceptor) Client {
+	return &interceptedClient{client: client, interceptors: interceptors}
+}
+
+func (c *interceptedClient) invoke(ctx context.Context, inv *Invocation, request Invoker) error {
+	next := request
+	for i := len(c.interceptors) - 1; i >= 0; i-- {
+		interceptor, n := c.interceptors[i], next
+		next = func(ctx context.Context) error {
+			return interceptor(ctx, inv, n)
+		}
+	}
+	return next(ctx)
+}
+
+func (c *interceptedClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *interceptedClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	inv := &Invocation{Kind: CallInvocation, Method: method, Args: args}
+	return c.invoke(ctx, inv, func(ctx context.Context) error {
+		return c.client.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (c *interceptedClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+func (c *interceptedClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	inv := &Invocation{Kind: BatchInvocation, Method: "batch", Batch: b}
+	return c.invoke(ctx, inv, func(ctx context.Context) error {
+		return c.client.BatchCallContext(ctx, b)
+	})
+}
+
+func (c *interceptedClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	var sub Subscription
+	inv := &Invocation{Kind: SubscribeInvocation, Method: methods.Subscribe, Args: args}
+	err := c.invoke(ctx, inv, func(ctx context.Context) error {
+		var err error
+		sub, err = c.client.Subscribe(ctx, methods, channel, args...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (c *interceptedClient) Close() {
+	c.client.Close()
+}
+
+// LogEntry is the record of a request passed to the logger of LoggingInterceptor
+type LogEntry struct {
+	Kind     InvocationKind
+	Method   string
+	Args     []interface{}
+	Duration time.Duration
+	Err      error
+}
+
+// LoggingInterceptor passes a LogEntry of every request to log
+func LoggingInterceptor(log func(entry LogEntry)) Interceptor {
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		start := time.Now()
+		err := next(ctx)
+		log(LogEntry{Kind: inv.Kind, Method: inv.Method, Args: inv.Args, Duration: time.Since(start), Err: err})
+		return err
+	}
+}
+
+// minRetryInterval is the shortest wait before a retry so that a retried request doesn't spin
+const minRetryInterval = 10 * time.Millisecond
+
+// RetryPolicy of a method
+type RetryPolicy struct {
+	MaxRetries int
+	// Interval is the wait before the first retry, doubled on every retry. The interval is at least 10ms.
+	Interval time.Duration
+}
+
+// readMethods are the idempotent methods retried by DefaultRetryPolicies
+var readMethods = []string{
+	"chain_getBlockHash",
+	"chain_getHeader",
+	"chain_getBlock",
+	"chain_getFinalizedHead",
+	"state_getMetadata",
+	"state_getStorage",
+	"state_getKeys",
+	"state_getRuntimeVersion",
+	"system_name",
+	"system_version",
+	"system_chain",
+	"system_health",
+	"grandpa_roundState",
+	"grandpa_proveFinality",
+}
+
+// DefaultRetryPolicies applies the policy to the idempotent read methods
+func DefaultRetryPolicies(policy RetryPolicy) map[string]RetryPolicy {
+	policies := make(map[string]RetryPolicy, len(readMethods))
+	for _, m := range readMethods {
+		policies[m] = policy
+	}
+	return policies
+}
+
+// RetryInterceptor retries the calls failed due to the connection with the policy of their method. A batch is
+// retried with the smallest policy of its methods if all of them have a policy. Subscriptions and the author_*
+// methods, eg: author_submitExtrinsic, are never retried.
+func RetryInterceptor(policies map[string]RetryPolicy) Interceptor {
+	policy := func(method string) (RetryPolicy, bool) {
+		if strings.HasPrefix(method, "author_") {
+			return RetryPolicy{}, false
+		}
+		p, ok := policies[method]
+		return p, ok
+	}
+
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		var p RetryPolicy
+		var ok bool
+		switch inv.Kind {
+		case CallInvocation:
+			p, ok = policy(inv.Method)
+		case BatchInvocation:
+			for i, e := range inv.Batch {
+				ep, eok := policy(e.Method)
+				if !eok {
+					ok = false
+					break
+				}
+				if i == 0 || ep.MaxRetries < p.MaxRetries {
+					p = ep
+				}
+				ok = true
+			}
+		}
+
+		if !ok {
+			return next(ctx)
+		}
+
+		wait := p.Interval
+		if wait < minRetryInterval {
+			wait = minRetryInterval
+		}
+		for i := 0; ; i++ {
+			err := next(ctx)
+			if i >= p.MaxRetries || !isConnectionError(err) {
+				return err
+			}
+
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wait *= 2
+		}
+	}
+}
+
+// MetricsInterceptor registers the substrate_rpc_request_duration_seconds histogram and the
+// substrate_rpc_request_errors_total counter, both by method, with the registerer. Every request of a batch is
+// observed under its method with the duration of the batch.
+func MetricsInterceptor(registerer prometheus.Registerer) (Interceptor, error) {
+	durations := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "substrate",
+		Subsystem: "rpc",
+		Name:      "request_duration_seconds",
+		Help:      "Duration of the RPC requests by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+	errs := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "substrate",
+		Subsystem: "rpc",
+		Name:      "request_errors_total",
+		Help:      "Failed RPC requests by method.",
+	}, []string{"method"})
+
+	for _, c := range []prometheus.Collector{durations, errs} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		start := time.Now()
+		err := next(ctx)
+		d := time.Since(start).Seconds()
+		if inv.Kind != BatchInvocation {
+			durations.WithLabelValues(inv.Method).Observe(d)
+			if err != nil {
+				errs.WithLabelValues(inv.Method).Inc()
+			}
+			return err
+		}
+
+		for _, e := range inv.Batch {
+			durations.WithLabelValues(e.Method).Observe(d)
+			if err != nil || e.Error != nil {
+				errs.WithLabelValues(e.Method).Inc()
+			}
+		}
+		return err
+	}, nil
+}
@@ -0,0 +1,129 @@
+package substrate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+// failingHandler fails with a connection error the given number of times
+func failingHandler(failures int, res interface{}) func(args ...interface{}) (interface{}, error) {
+	return func(args ...interface{}) (interface{}, error) {
+		if failures > 0 {
+			failures--
+			return nil, errConnectionLost
+		}
+		return res, nil
+	}
+}
+
+func TestWithInterceptors_Logging(t *testing.T) {
+	m := newMockClient()
+	m.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return "node", nil
+	})
+
+	var entries []LogEntry
+	var order []string
+	c := WithInterceptors(m, LoggingInterceptor(func(entry LogEntry) {
+		entries = append(entries, entry)
+		order = append(order, "log")
+	}), LoggingInterceptor(func(entry LogEntry) {
+		order = append(order, "inner")
+	}))
+
+	var res string
+	assert.NoError(t, c.Call(&res, "system_name", 1))
+	assert.Equal(t, "node", res)
+	assert.Error(t, c.Call(&res, "system_chain"))
+	assert.NoError(t, c.BatchCall([]rpc.BatchElem{{Method: "system_name", Result: &res}}))
+
+	assert.Len(t, entries, 3)
+	assert.Equal(t, "system_name", entries[0].Method)
+	assert.Equal(t, []interface{}{1}, entries[0].Args)
+	assert.NoError(t, entries[0].Err)
+	assert.Error(t, entries[1].Err)
+	assert.Equal(t, BatchInvocation, entries[2].Kind)
+	assert.Equal(t, []string{"inner", "log", "inner", "log", "inner", "log"}, order)
+}
+
+func TestRetryInterceptor(t *testing.T) {
+	m := newMockClient()
+	policies := DefaultRetryPolicies(RetryPolicy{MaxRetries: 2, Interval: time.Millisecond})
+	policies["author_submitExtrinsic"] = RetryPolicy{MaxRetries: 2}
+	c := WithInterceptors(m, RetryInterceptor(policies))
+
+	var res string
+	m.handle("system_name", failingHandler(2, "node"))
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.Equal(t, 3, m.callCount("system_name"))
+
+	m.handle("system_chain", failingHandler(3, "dev"))
+	assert.Error(t, c.Call(&res, "system_chain"))
+	assert.Equal(t, 3, m.callCount("system_chain"))
+
+	// errors of the node are not retried
+	m.handle("chain_getHeader", func(args ...interface{}) (interface{}, error) {
+		return nil, testRPCError{}
+	})
+	assert.Error(t, c.Call(&res, "chain_getHeader"))
+	assert.Equal(t, 1, m.callCount("chain_getHeader"))
+
+	// the retries wait at least the minimum interval
+	c = WithInterceptors(m, RetryInterceptor(map[string]RetryPolicy{"system_version": {MaxRetries: 2}}))
+	m.handle("system_version", failingHandler(2, "1.0"))
+	start := time.Now()
+	assert.NoError(t, c.Call(&res, "system_version"))
+	assert.True(t, time.Since(start) >= 3*minRetryInterval)
+
+	// never retried
+	m.handle("author_submitExtrinsic", failingHandler(1, "0x01"))
+	assert.Error(t, c.Call(&res, "author_submitExtrinsic"))
+	assert.Equal(t, 1, m.callCount("author_submitExtrinsic"))
+}
+
+func TestMetricsInterceptor(t *testing.T) {
+	m := newMockClient()
+	m.handle("system_name", func(args ...interface{}) (interface{}, error) {
+		return "node", nil
+	})
+
+	reg := prometheus.NewRegistry()
+	metrics, err := MetricsInterceptor(reg)
+	assert.NoError(t, err)
+	c := WithInterceptors(m, metrics)
+
+	var res string
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.Error(t, c.Call(&res, "system_chain"))
+
+	// the requests of a batch are observed by method
+	var name, chain string
+	assert.NoError(t, c.BatchCall([]rpc.BatchElem{{Method: "system_name", Result: &name},
+		{Method: "system_chain", Result: &chain}}))
+
+	families, err := reg.Gather()
+	assert.NoError(t, err)
+	counts := make(map[string]map[string]uint64)
+	for _, f := range families {
+		counts[f.GetName()] = make(map[string]uint64)
+		for _, metric := range f.GetMetric() {
+			method := metric.GetLabel()[0].GetValue()
+			if f.GetName() == "substrate_rpc_request_duration_seconds" {
+				counts[f.GetName()][method] = metric.GetHistogram().GetSampleCount()
+			} else {
+				counts[f.GetName()][method] = uint64(metric.GetCounter().GetValue())
+			}
+		}
+	}
+
+	assert.Equal(t, map[string]uint64{"system_name": 3, "system_chain": 2}, counts["substrate_rpc_request_duration_seconds"])
+	assert.Equal(t, map[string]uint64{"system_chain": 2}, counts["substrate_rpc_request_errors_total"])
+
+	_, err = MetricsInterceptor(reg)
+	assert.Error(t, err)
+}
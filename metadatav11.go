@@ -0,0 +1,110 @@
+package substrate
+
+import (
+	"fmt"
+
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+// v11Hashers maps the StorageHasher enum of the metadata v11 to the storage hashers
+var v11Hashers = []uint8{Blake2_128, Blake2_256, Blake2_128Concat, Twox128, Twox256, Twox64Concat, Identity}
+
+// ModuleConstantMetadata is a constant of a module, the value is SCALE encoded
+type ModuleConstantMetadata struct {
+	Name          string
+	Type          string
+	Value         []byte
+	Documentation []string
+}
+
+func (m *ModuleConstantMetadata) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&m.Name)
+	decoder.Decode(&m.Type)
+	decoder.Decode(&m.Value)
+	decoder.Decode(&m.Documentation)
+}
+
+// ErrorMetadata is an error of a module, the errors are indexed in the order of the module
+type ErrorMetadata struct {
+	Name          string
+	Documentation []string
+}
+
+func (m *ErrorMetadata) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Decode(&m.Name)
+	decoder.Decode(&m.Documentation)
+}
+
+// decodeV11Modules decodes the modules of the metadata v11 and v12 in to the module metadata, the v12 modules are
+// indexed by the runtime
+func decodeV11Modules(decoder scalecodec.Decoder, version uint8) []ModuleMetaData {
+	modules := make([]ModuleMetaData, decoder.DecodeUintCompact())
+	for i := range modules {
+		modules[i].decodeV11(decoder, version)
+	}
+	return modules
+}
+
+func (m *ModuleMetaData) decodeV11(decoder scalecodec.Decoder, version uint8) {
+	decoder.Decode(&m.Name)
+
+	decoder.Decode(&m.StorageOptional)
+	if m.StorageOptional == 1 {
+		decoder.Decode(&m.Prefix)
+		m.Storage = make([]StorageFunctionMetadata, decoder.DecodeUintCompact())
+		for i := range m.Storage {
+			m.Storage[i].decodeV11(decoder)
+		}
+	}
+
+	decoder.Decode(&m.CallsOptional)
+	if m.CallsOptional == 1 {
+		decoder.Decode(&m.Calls)
+	}
+
+	decoder.Decode(&m.EventsOptional)
+	if m.EventsOptional == 1 {
+		decoder.Decode(&m.Events)
+	}
+
+	decoder.Decode(&m.Constants)
+	decoder.Decode(&m.Errors)
+	if version >= 12 {
+		decoder.Decode(&m.Index)
+	}
+}
+
+func (m *StorageFunctionMetadata) decodeV11(decoder scalecodec.Decoder) {
+	decoder.Decode(&m.Name)
+	decoder.Decode(&m.Modifier)
+	decoder.Decode(&m.Type)
+	switch m.Type {
+	case 0:
+		decoder.Decode(&m.Plane)
+	case 1:
+		m.Map.Hasher = decodeV11Hasher(decoder)
+		decoder.Decode(&m.Map.Key)
+		decoder.Decode(&m.Map.Value)
+		// unused
+		decoder.Decode(&m.Map.IsLinked)
+	case 2:
+		m.DMap.Hasher = decodeV11Hasher(decoder)
+		decoder.Decode(&m.DMap.Key)
+		decoder.Decode(&m.DMap.Key2)
+		decoder.Decode(&m.DMap.Value)
+		// the hasher of the second key is skipped, the double maps are not supported
+		decodeV11Hasher(decoder)
+	default:
+		panic(fmt.Sprintf("unknown storage entry type %d", m.Type))
+	}
+	decoder.Decode(&m.Fallback)
+	decoder.Decode(&m.Documentation)
+}
+
+func decodeV11Hasher(decoder scalecodec.Decoder) uint8 {
+	h := decoder.ReadOneByte()
+	if int(h) >= len(v11Hashers) {
+		panic(fmt.Sprintf("unknown storage hasher %d", h))
+	}
+	return v11Hashers[h]
+}
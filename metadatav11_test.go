@@ -0,0 +1,174 @@
+package substrate
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+// encodeTestMetadata encodes the modules of the test metadata as the metadata v11 or v12 with the signed extensions,
+// the v12 modules are indexed by their position. The modules are capitalised as by the runtimes, eg: System.
+func encodeTestMetadata(t *testing.T, version uint8, extensions []string) string {
+	return encodeTestMetadataOf(t, testMetadata(t), version, extensions)
+}
+
+// encodeTestMetadataOf encodes the modules of the v4 metadata as the metadata v11 or v12, see encodeTestMetadata
+func encodeTestMetadataOf(t *testing.T, meta *MetadataVersioned, version uint8, extensions []string) string {
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	enc.Encode(uint32(0x6174656d))
+	enc.Encode(version)
+	enc.EncodeUintCompact(uint64(len(meta.Metadata.Modules)))
+	for i, m := range meta.Metadata.Modules {
+		enc.Encode(testModuleName(m.Name))
+		enc.Encode(m.StorageOptional)
+		if m.StorageOptional == 1 {
+			enc.Encode(m.Prefix)
+			enc.EncodeUintCompact(uint64(len(m.Storage)))
+			for _, s := range m.Storage {
+				enc.Encode(s.Name)
+				enc.Encode(s.Modifier)
+				enc.Encode(s.Type)
+				switch s.Type {
+				case 0:
+					enc.Encode(s.Plane)
+				case 1:
+					enc.Encode(testV11Hasher(s.Map.Hasher))
+					enc.Encode(s.Map.Key)
+					enc.Encode(s.Map.Value)
+					enc.Encode(false)
+				default:
+					t.Fatalf("double map %s", s.Name)
+				}
+				enc.Encode(s.Fallback)
+				enc.Encode(s.Documentation)
+			}
+		}
+
+		enc.Encode(m.CallsOptional)
+		if m.CallsOptional == 1 {
+			enc.EncodeUintCompact(uint64(len(m.Calls)))
+			for _, c := range m.Calls {
+				enc.Encode(c.Name)
+				enc.EncodeUintCompact(uint64(len(c.Args)))
+				for _, a := range c.Args {
+					enc.Encode(a.Name)
+					enc.Encode(a.Type)
+				}
+				enc.Encode(c.Documentation)
+			}
+		}
+
+		enc.Encode(m.EventsOptional)
+		if m.EventsOptional == 1 {
+			enc.EncodeUintCompact(uint64(len(m.Events)))
+			for _, e := range m.Events {
+				enc.Encode(e.Name)
+				enc.Encode(e.Args)
+				enc.Encode(e.Documentation)
+			}
+		}
+
+		// a constant and an error
+		enc.EncodeUintCompact(1)
+		enc.Encode("Version")
+		enc.Encode("u32")
+		enc.Encode([]byte{1, 0, 0, 0})
+		enc.Encode([]string{" The version."})
+		enc.EncodeUintCompact(1)
+		enc.Encode("Failed")
+		enc.Encode([]string{" The call failed."})
+		if version >= 12 {
+			enc.Encode(uint8(i))
+		}
+	}
+
+	enc.Encode(uint8(ExtrinsicVersion4))
+	enc.Encode(extensions)
+	return hexutil.Encode(bb.Bytes())
+}
+
+// testModuleName capitalises the name of a module of the metadata v4, eg: system is named System from v11
+func testModuleName(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+func testV11Hasher(hasher uint8) uint8 {
+	for i, h := range v11Hashers {
+		if h == hasher {
+			return uint8(i)
+		}
+	}
+	panic("unknown hasher")
+}
+
+func TestMetadataVersioned_V11(t *testing.T) {
+	v4 := testMetadata(t)
+	for _, version := range []uint8{11, 12} {
+		meta, err := decodeMetadata(encodeTestMetadata(t, version, testSignedExtensions))
+		assert.NoError(t, err)
+		assert.Equal(t, version, meta.Version)
+		assert.Equal(t, testSignedExtensions, meta.Extrinsic.SignedExtensions)
+		assert.Equal(t, uint8(ExtrinsicVersion4), meta.Extrinsic.Version)
+		assert.Len(t, meta.Metadata.Modules, len(v4.Metadata.Modules))
+		for i, m := range meta.Metadata.Modules {
+			assert.Equal(t, testModuleName(v4.Metadata.Modules[i].Name), m.Name)
+			assert.Equal(t, v4.Metadata.Modules[i].Prefix, m.Prefix)
+			assert.Equal(t, v4.Metadata.Modules[i].Storage, m.Storage)
+			assert.Equal(t, v4.Metadata.Modules[i].Calls, m.Calls)
+			assert.Equal(t, v4.Metadata.Modules[i].Events, m.Events)
+			assert.Equal(t, []ModuleConstantMetadata{{Name: "Version", Type: "u32", Value: []byte{1, 0, 0, 0},
+				Documentation: []string{" The version."}}}, m.Constants)
+			assert.Equal(t, "Failed", m.Errors[0].Name)
+		}
+
+		// the storage keys are the hashes of the prefix and the name, the module is found whatever its case
+		key, err := meta.Metadata.StorageKey("System", "Number")
+		assert.NoError(t, err)
+		assert.Equal(t, "0x26aa394eea5630e07c48ae0c9558cef702a5c1b19ab7a04f536c519aca4983ac", hexutil.Encode(key))
+		lower, err := meta.Metadata.StorageKey("system", "Number")
+		assert.NoError(t, err)
+		assert.Equal(t, key, lower)
+		key, err = meta.Metadata.StorageKey("system", "AccountNonce", make([]byte, 32))
+		assert.NoError(t, err)
+		assert.Equal(t, append(append(hash(Twox128, []byte("System")), hash(Twox128, []byte("AccountNonce"))...),
+			hash(Blake2_256, make([]byte, 32))...), key)
+	}
+
+	// the calls are indexed by the position of their module among the modules with calls before v12
+	v11, err := decodeMetadata(encodeTestMetadata(t, 11, nil))
+	assert.NoError(t, err)
+	v12, err := decodeMetadata(encodeTestMetadata(t, 12, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, v4.Metadata.MethodIndex("kerplunk.commit"), v11.Metadata.MethodIndex("Kerplunk.commit"))
+	var position int
+	for i, m := range v12.Metadata.Modules {
+		if m.Name == "Kerplunk" {
+			position = i
+		}
+	}
+	idx := v12.Metadata.MethodIndex("Kerplunk.commit")
+	assert.Equal(t, MethodIDX{SectionIndex: uint8(position), MethodIndex: 0}, idx)
+	_, call, err := v12.Metadata.FindCall(idx)
+	assert.NoError(t, err)
+	assert.Equal(t, "commit", call.Name)
+}
+
+func TestMetadataVersioned_Unsupported(t *testing.T) {
+	// only v4, v11 and v12 are decoded
+	b := hexutil.MustDecode(encodeTestMetadata(t, 11, testSignedExtensions))
+	b[4] = 10
+	_, err := decodeMetadata(hexutil.Encode(b))
+	assert.EqualError(t, err, "failed to decode metadata: unsupported metadata version 10")
+
+	// the runtimes of v4 sign no extensions
+	v4, err := decodeMetadata(substratetest.MetadataV4)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(4), v4.Version)
+	assert.Empty(t, v4.Extrinsic.SignedExtensions)
+}
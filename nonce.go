@@ -0,0 +1,193 @@
+package substrate
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/vimukthi-git/go-substrate/signature"
+)
+
+// errCodeMethodNotFound is the JSON-RPC error of an unknown method
+const errCodeMethodNotFound = -32601
+
+// NonceManager allocates the nonces of the extrinsics of an account. The next nonce is synchronised from the chain
+// on first use and after a Resync, the nonces released by failed submissions are reused before the next nonce.
+type NonceManager struct {
+	client  Client
+	account []byte
+	meta    *MetadataVersioned
+
+	mu       sync.Mutex
+	synced   bool
+	next     uint64
+	released []uint64
+}
+
+// NewNonceManager manages the nonces of the account ID, the metadata of any supported version is used to read the
+// System.AccountNonce storage of the nodes without system_accountNextIndex and may be nil
+func NewNonceManager(client Client, accountID []byte, meta *MetadataVersioned) *NonceManager {
+	return &NonceManager{client: client, account: accountID, meta: meta}
+}
+
+// ChainNonce returns the next nonce of the account from system_accountNextIndex, including the extrinsics in the
+// pool, or else from the System.AccountNonce storage of the best block
+func (m *NonceManager) ChainNonce(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	meta := m.meta
+	m.mu.Unlock()
+	return m.chainNonce(ctx, meta)
+}
+
+// chainNonce returns the chain nonce, reading the storage with the given metadata
+func (m *NonceManager) chainNonce(ctx context.Context, meta *MetadataVersioned) (uint64, error) {
+	addr, err := signature.SS58Address(m.account)
+	if err != nil {
+		return 0, err
+	}
+
+	var nonce uint64
+	err = m.client.CallContext(ctx, &nonce, "system_accountNextIndex", addr)
+	var rerr rpc.Error
+	if err == nil || !errors.As(err, &rerr) || rerr.ErrorCode() != errCodeMethodNotFound || meta == nil {
+		return nonce, err
+	}
+
+	key, err := meta.Metadata.StorageKey("system", "AccountNonce", m.account)
+	if err != nil {
+		return 0, err
+	}
+
+	b, err := NewStateRPC(m.client).Storage(ctx, key, nil)
+	if err != nil || len(b) == 0 {
+		return 0, err
+	}
+
+	var v [8]byte
+	copy(v[:], b)
+	return binary.LittleEndian.Uint64(v[:]), nil
+}
+
+// Next allocates the lowest released nonce or else the next nonce
+func (m *NonceManager) Next(ctx context.Context) (uint64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.synced {
+		err := m.sync(ctx)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if len(m.released) > 0 {
+		n := m.released[0]
+		m.released = m.released[1:]
+		return n, nil
+	}
+
+	n := m.next
+	m.next++
+	return n, nil
+}
+
+// Release returns the nonce of an extrinsic that was not submitted so that it is reused
+func (m *NonceManager) Release(nonce uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.synced || nonce >= m.next {
+		return
+	}
+
+	i := sort.Search(len(m.released), func(i int) bool { return m.released[i] >= nonce })
+	if i < len(m.released) && m.released[i] == nonce {
+		return
+	}
+	m.released = append(m.released, 0)
+	copy(m.released[i+1:], m.released[i:])
+	m.released[i] = nonce
+
+	// the released nonces at the end are allocated as the next nonces
+	for len(m.released) > 0 && m.released[len(m.released)-1] == m.next-1 {
+		m.released = m.released[:len(m.released)-1]
+		m.next--
+	}
+}
+
+// Set sets the next nonce and forgets the released nonces, eg: to start from a known nonce
+func (m *NonceManager) Set(next uint64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = true
+	m.next = next
+	m.released = nil
+}
+
+// Resync synchronises the next nonce from the chain and forgets the released nonces
+func (m *NonceManager) Resync(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.sync(ctx)
+}
+
+// setMetadata sets the metadata used to read the System.AccountNonce storage, eg: after a runtime upgrade
+func (m *NonceManager) setMetadata(meta *MetadataVersioned) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.meta = meta
+}
+
+// invalidate synchronises the next nonce from the chain on the next allocation
+func (m *NonceManager) invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.synced = false
+	m.released = nil
+}
+
+// sync sets the next nonce to the chain nonce, the lock must be held
+func (m *NonceManager) sync(ctx context.Context) error {
+	n, err := m.chainNonce(ctx, m.meta)
+	if err != nil {
+		return err
+	}
+
+	m.synced = true
+	m.next = n
+	m.released = nil
+	return nil
+}
+
+// IsNonceError reports whether the transaction pool rejected an extrinsic for its nonce: the nonce is outdated,
+// too far in the future or already used by an extrinsic in the pool. Substrate sends the reason of an Invalid
+// Transaction in the data of the error, eg: "Transaction is outdated".
+func IsNonceError(err error) bool {
+	var rerr rpc.Error
+	if !errors.As(err, &rerr) {
+		return false
+	}
+
+	switch rerr.ErrorCode() {
+	case ErrCodeAlreadyImported, ErrCodeTooLowPriority:
+		return true
+	case ErrCodeInvalidTransaction:
+		reason := strings.ToLower(errorReason(err))
+		return strings.Contains(reason, "outdated") || strings.Contains(reason, "stale") || strings.Contains(reason, "future")
+	}
+	return false
+}
+
+// errorReason returns the data of the RPC error, or its message if it has no data
+func errorReason(err error) string {
+	var derr DataError
+	if errors.As(err, &derr) {
+		if data := derr.ErrorData(); data != nil {
+			return fmt.Sprint(data)
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,180 @@
+package substrate
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestNonceManager_Release(t *testing.T) {
+	m := NewNonceManager(nil, nil, nil)
+	m.Set(5)
+	ctx := context.Background()
+	for _, n := range []uint64{5, 6, 7, 8} {
+		next, err := m.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, n, next)
+	}
+
+	// the released nonces are reused in order
+	m.Release(7)
+	m.Release(5)
+	m.Release(5)
+	m.Release(9)
+	for _, n := range []uint64{5, 7, 9} {
+		next, err := m.Next(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, n, next)
+	}
+
+	// releasing the last nonces rewinds the next nonce
+	m.Release(8)
+	m.Release(9)
+	m.Release(7)
+	assert.Equal(t, uint64(7), m.next)
+	assert.Empty(t, m.released)
+}
+
+func TestNonceManager_ChainNonce(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	a := newTestAuthor(t, c)
+	var alice [32]byte
+	copy(alice[:], a.account.PublicKey)
+	n.SetNonce(alice, 3)
+	ctx := context.Background()
+	m := NewNonceManager(c, a.account.PublicKey, testMetadata(t))
+	nonce, err := m.ChainNonce(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	// the ready extrinsics of the pool are included, the future ones are not
+	a1, _ := testAnchorCall()
+	for _, nonce := range []uint64{3, 5} {
+		a.nonces.Set(nonce)
+		_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+		assert.NoError(t, err)
+	}
+	next, err := m.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(4), next)
+
+	// System.AccountNonce without system_accountNextIndex
+	key, err := testMetadata(t).Metadata.StorageKey("system", "AccountNonce", a.account.PublicKey)
+	assert.NoError(t, err)
+	v := make([]byte, 8)
+	binary.LittleEndian.PutUint64(v, 7)
+	n.SetStorage(key, v)
+	n.ProduceBlock()
+	f := NewFaultInjector(c, 0, FaultRule{Method: "system_accountNextIndex", Fault: Fault{ErrorCode: errCodeMethodNotFound}})
+	m = NewNonceManager(f, a.account.PublicKey, testMetadata(t))
+	assert.NoError(t, m.Resync(ctx))
+	next, err = m.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(7), next)
+
+	// System.AccountNonce of the v11 metadata, the module is capitalised and its key hashed
+	v11, err := decodeMetadata(encodeTestMetadata(t, 11, nil))
+	assert.NoError(t, err)
+	key = append(append(hash(Twox128, []byte("System")), hash(Twox128, []byte("AccountNonce"))...),
+		hash(Blake2_256, a.account.PublicKey)...)
+	binary.LittleEndian.PutUint64(v, 9)
+	n.SetStorage(key, v)
+	n.ProduceBlock()
+	m = NewNonceManager(f, a.account.PublicKey, v11)
+	next, err = m.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(9), next)
+
+	m = NewNonceManager(f, a.account.PublicKey, nil)
+	_, err = m.Next(ctx)
+	assert.Error(t, err)
+}
+
+func TestAuthor_SubmitExtrinsic_NonceRecovery(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	var alice [32]byte
+	copy(alice[:], pair.PublicKey())
+	n.SetNonce(alice, 2)
+
+	ctx := context.Background()
+	f := NewFaultInjector(c, 0, FaultRule{Method: "author_submitExtrinsic", Count: 1,
+		Fault: Fault{ErrorCode: ErrCodeImmediatelyDropped}})
+	a := NewAuthorRPC(0, make([]byte, 32), NewKeyringAccount(pair), *testMetadata(t), f)
+	a1, _ := testAnchorCall()
+	a2 := a1
+	a2.Proof[0] = 4
+
+	// the outdated start nonce is synchronised from the chain
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, ErrCodeImmediatelyDropped, err)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, ErrCodeInvalidTransaction, err)
+	assert.True(t, IsNonceError(err))
+
+	// the nonce of the dropped extrinsic is reused
+	f.SetRules(FaultRule{Method: "author_submitExtrinsic", Count: 1, Fault: Fault{ErrorCode: ErrCodeImmediatelyDropped}})
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, ErrCodeImmediatelyDropped, err)
+	assert.False(t, IsNonceError(err))
+	for _, args := range []testAnchorParams{a1, a2} {
+		_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", args)
+		assert.NoError(t, err)
+	}
+
+	n.ProduceBlock()
+	assert.Empty(t, n.Pending())
+	assert.Equal(t, uint64(4), n.Nonce(alice))
+}
+
+func TestIsNonceError(t *testing.T) {
+	// substrate sends the reason of an invalid transaction in the data of the error
+	outdated := &jsonError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction", Data: json.RawMessage(`"Transaction is outdated"`)}
+	assert.True(t, IsNonceError(outdated))
+	assert.True(t, IsNonceError(fmt.Errorf("submit failed: %w", outdated)))
+	future := &jsonError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction", Data: json.RawMessage(`"Transaction will be valid in the future"`)}
+	assert.True(t, IsNonceError(future))
+	badSignature := &jsonError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction", Data: json.RawMessage(`"Transaction has a bad signature"`)}
+	assert.False(t, IsNonceError(badSignature))
+	assert.True(t, IsNonceError(&jsonError{Code: ErrCodeTooLowPriority, Message: "Priority is too low: (5 vs 5)"}))
+	assert.False(t, IsNonceError(&jsonError{Code: ErrCodeImmediatelyDropped, Message: "Immediately Dropped"}))
+	assert.False(t, IsNonceError(errConnectionLost))
+}
+
+func TestAuthor_SubmitExtrinsic_InvalidTransaction(t *testing.T) {
+	m := newMockClient()
+	m.handle("author_submitExtrinsic", func(args ...interface{}) (interface{}, error) {
+		return nil, &jsonError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction",
+			Data: json.RawMessage(`"Transaction has a bad signature"`)}
+	})
+	m.handle("system_accountNextIndex", func(args ...interface{}) (interface{}, error) {
+		return 3, nil
+	})
+	a := newTestAuthor(t, m)
+
+	// the nonce is synchronised from the chain after any invalid transaction instead of being reused
+	a1, _ := testAnchorCall()
+	_, err := a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assertPoolError(t, ErrCodeInvalidTransaction, err)
+	next, err := a.Nonces().Next(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), next)
+	assert.Equal(t, 1, m.callCount("system_accountNextIndex"))
+}
@@ -0,0 +1,149 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ExtrinsicReceipt is the outcome of an extrinsic included in a block
+type ExtrinsicReceipt struct {
+	BlockHash      Hash
+	BlockNumber    uint64
+	ExtrinsicIndex uint32
+	ExtrinsicHash  Hash
+	// Events emitted in the ApplyExtrinsic phase of the extrinsic
+	Events  []EventRecord
+	Success bool
+	// DispatchError is set when the extrinsic failed and the runtime reports the error
+	DispatchError *DispatchError
+}
+
+// Events decodes the System.Events storage of the block
+func (c *Chain) Events(ctx context.Context, blockHash Hash, meta *MetadataVersioned) ([]EventRecord, error) {
+	key, err := meta.Metadata.StorageKey("system", "Events")
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := NewStateRPC(c.client).Storage(ctx, key, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if b == nil {
+		return nil, nil
+	}
+
+	return c.registry.DecodeEventRecords(b, meta)
+}
+
+// bestBlockHash returns the block hash, or the hash of the best block when it is empty, so that the block and its
+// events are queried at the same block
+func (c *Chain) bestBlockHash(ctx context.Context, blockHash Hash) (Hash, error) {
+	if len(blockHash) != 0 {
+		return blockHash, nil
+	}
+
+	var res Hash
+	err := c.client.CallContext(ctx, &res, "chain_getBlockHash")
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ExtrinsicReceipt finds the extrinsic with the given hash in the block and returns its outcome, the best block is
+// used when the block hash is empty
+func (c *Chain) ExtrinsicReceipt(ctx context.Context, blockHash, extrinsicHash Hash, meta *MetadataVersioned) (*ExtrinsicReceipt, error) {
+	blockHash, err := c.bestBlockHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.Block(ctx, blockHash, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, e := range b.Block.Extrinsics {
+		if bytes.Equal(e.Hash(), extrinsicHash) {
+			return c.receipt(ctx, blockHash, b, uint32(i), meta)
+		}
+	}
+
+	return nil, fmt.Errorf("extrinsic %s not found in block %s", extrinsicHash.String(), blockHash.String())
+}
+
+// ExtrinsicReceiptByIndex returns the outcome of the extrinsic at the given index in the block, the best block is
+// used when the block hash is empty
+func (c *Chain) ExtrinsicReceiptByIndex(ctx context.Context, blockHash Hash, index uint32, meta *MetadataVersioned) (*ExtrinsicReceipt, error) {
+	blockHash, err := c.bestBlockHash(ctx, blockHash)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := c.Block(ctx, blockHash, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	if int(index) >= len(b.Block.Extrinsics) {
+		return nil, fmt.Errorf("extrinsic index %d not found in block %s", index, blockHash.String())
+	}
+
+	return c.receipt(ctx, blockHash, b, index, meta)
+}
+
+func (c *Chain) receipt(ctx context.Context, blockHash Hash, b *SignedBlock, index uint32, meta *MetadataVersioned) (*ExtrinsicReceipt, error) {
+	events, err := c.Events(ctx, blockHash, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewExtrinsicReceipt(blockHash, b, index, events)
+}
+
+// NewExtrinsicReceipt correlates the events of the block with the extrinsic at the given index
+func NewExtrinsicReceipt(blockHash Hash, b *SignedBlock, index uint32, events []EventRecord) (*ExtrinsicReceipt, error) {
+	r := &ExtrinsicReceipt{
+		BlockHash:      blockHash,
+		BlockNumber:    b.Block.Header.Number,
+		ExtrinsicIndex: index,
+		ExtrinsicHash:  b.Block.Extrinsics[index].Hash(),
+	}
+
+	var found bool
+	for _, e := range events {
+		if !e.Phase.IsApplyExtrinsic || e.Phase.ApplyExtrinsic != index {
+			continue
+		}
+
+		r.Events = append(r.Events, e)
+		// the module is named System from v11
+		if !strings.EqualFold(e.Event.Module, "system") {
+			continue
+		}
+
+		switch e.Event.Name {
+		case "ExtrinsicSuccess":
+			found = true
+			r.Success = true
+		case "ExtrinsicFailed":
+			found = true
+			for _, a := range e.Event.Args {
+				if d, ok := a.(DispatchError); ok {
+					r.DispatchError = &d
+				}
+			}
+		}
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no outcome event found for extrinsic %d in block %s", index, blockHash.String())
+	}
+
+	return r, nil
+}
@@ -0,0 +1,395 @@
+package substrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ConnectionState of a ReconnectingClient
+type ConnectionState uint8
+
+const (
+	// Connected is reported when a connection is (re)established
+	Connected ConnectionState = iota
+	// Disconnected is reported when the connection is lost, reconnecting starts right after
+	Disconnected
+	// Closed is reported when the client is closed
+	Closed
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connected:
+		return "Connected"
+	case Disconnected:
+		return "Disconnected"
+	case Closed:
+		return "Closed"
+	default:
+		return fmt.Sprintf("ConnectionState(%d)", s)
+	}
+}
+
+// DialFunc opens a new connection
+type DialFunc func(ctx context.Context) (Client, error)
+
+// ReconnectConfig configures a ReconnectingClient
+type ReconnectConfig struct {
+	// MinBackoff is the wait before the first reconnect attempt, doubled on every failed attempt. Defaults to 100ms.
+	MinBackoff time.Duration
+	// MaxBackoff caps the wait between reconnect attempts, defaults to 30s
+	MaxBackoff time.Duration
+	// OnStateChange is called with the connection state changes and the error that caused a disconnect,
+	// it must not block
+	OnStateChange func(state ConnectionState, err error)
+}
+
+// ReconnectingClient is a Client that reconnects with exponential backoff when the connection is lost and
+// re-establishes the active subscriptions on the new connection.
+// Calls made while disconnected wait for the reconnect or their context. Failed calls are not retried since
+// they may not be idempotent, eg: author_submitExtrinsic. Notifications sent while disconnected are lost, and a
+// resubscribed extrinsic watch resubmits the extrinsic.
+type ReconnectingClient struct {
+	dial   DialFunc
+	config ReconnectConfig
+
+	mu   sync.Mutex
+	conn Client
+	// gen is incremented on every new connection so that failures of old connections are ignored
+	gen uint64
+	// ready is closed once conn is set
+	ready chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// ConnectWithReconnect connects to the given url, the client reconnects when the connection is lost
+func ConnectWithReconnect(ctx context.Context, url string, config ReconnectConfig) (*ReconnectingClient, error) {
+	return NewReconnectingClient(ctx, func(ctx context.Context) (Client, error) {
+		return ConnectContext(ctx, url)
+	}, config)
+}
+
+// NewReconnectingClient opens the first connection with dial and returns its error if it fails
+func NewReconnectingClient(ctx context.Context, dial DialFunc, config ReconnectConfig) (*ReconnectingClient, error) {
+	if config.MinBackoff <= 0 {
+		config.MinBackoff = 100 * time.Millisecond
+	}
+	if config.MaxBackoff <= 0 {
+		config.MaxBackoff = 30 * time.Second
+	}
+
+	conn, err := dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rctx, cancel := context.WithCancel(context.Background())
+	c := &ReconnectingClient{dial: dial, config: config, conn: conn, ready: make(chan struct{}), ctx: rctx, cancel: cancel}
+	close(c.ready)
+	c.notify(Connected, nil)
+	return c, nil
+}
+
+func (c *ReconnectingClient) notify(state ConnectionState, err error) {
+	if c.config.OnStateChange != nil {
+		c.config.OnStateChange(state, err)
+	}
+}
+
+// connection waits for a connection and returns it with its generation
+func (c *ReconnectingClient) connection(ctx context.Context) (Client, uint64, error) {
+	for {
+		c.mu.Lock()
+		conn, gen, ready := c.conn, c.gen, c.ready
+		c.mu.Unlock()
+		if c.ctx.Err() != nil {
+			return nil, 0, rpc.ErrClientQuit
+		}
+
+		if conn != nil {
+			return conn, gen, nil
+		}
+
+		select {
+		case <-ready:
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-c.ctx.Done():
+			return nil, 0, rpc.ErrClientQuit
+		}
+	}
+}
+
+// fail drops the connection of the given generation and starts reconnecting
+func (c *ReconnectingClient) fail(gen uint64, err error) {
+	c.mu.Lock()
+	if c.conn == nil || c.gen != gen || c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return
+	}
+
+	conn := c.conn
+	c.conn = nil
+	c.ready = make(chan struct{})
+	c.mu.Unlock()
+
+	conn.Close()
+	c.notify(Disconnected, err)
+	go c.reconnect()
+}
+
+func (c *ReconnectingClient) reconnect() {
+	wait := c.config.MinBackoff
+	for {
+		select {
+		case <-time.After(wait):
+		case <-c.ctx.Done():
+			return
+		}
+
+		conn, err := c.dial(c.ctx)
+		if err != nil {
+			wait *= 2
+			if wait > c.config.MaxBackoff {
+				wait = c.config.MaxBackoff
+			}
+			continue
+		}
+
+		c.mu.Lock()
+		if c.ctx.Err() != nil {
+			c.mu.Unlock()
+			conn.Close()
+			return
+		}
+		c.conn = conn
+		c.gen++
+		close(c.ready)
+		c.mu.Unlock()
+
+		c.notify(Connected, nil)
+		return
+	}
+}
+
+// isConnectionError reports whether the error is due to the connection rather than the request: the connection was
+// closed by the node or by the client, or the transport failed
+func isConnectionError(err error) bool {
+	// context.DeadlineExceeded is a net.Error
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, rpc.ErrClientQuit) {
+		return true
+	}
+
+	// dial, read and write failures, including the writes to a closed websocket
+	var nerr net.Error
+	return errors.As(err, &nerr)
+}
+
+// do runs the request on the current connection and drops the connection if the request failed due to it
+func (c *ReconnectingClient) do(ctx context.Context, request func(conn Client) error) error {
+	conn, gen, err := c.connection(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = request(conn)
+	if isConnectionError(err) && ctx.Err() == nil {
+		c.fail(gen, err)
+	}
+	return err
+}
+
+func (c *ReconnectingClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *ReconnectingClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.do(ctx, func(conn Client) error {
+		return conn.CallContext(ctx, result, method, args...)
+	})
+}
+
+func (c *ReconnectingClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+func (c *ReconnectingClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return c.do(ctx, func(conn Client) error {
+		return conn.BatchCallContext(ctx, b)
+	})
+}
+
+// Subscribe subscribes on the current connection, the subscription is re-established on every new connection
+// until it is unsubscribed or the context is done. The subscription ends with an error if the node rejects the
+// resubscription.
+func (c *ReconnectingClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	s := &reconnectingSubscription{
+		client:  c,
+		ctx:     ctx,
+		methods: methods,
+		channel: channel,
+		args:    args,
+		err:     make(chan error, 1),
+		quit:    make(chan struct{}),
+	}
+
+	sub, gen, err := s.subscribe(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	go s.forward(sub, gen)
+	return s, nil
+}
+
+// Close closes the connection and ends the subscriptions
+func (c *ReconnectingClient) Close() {
+	c.mu.Lock()
+	if c.ctx.Err() != nil {
+		c.mu.Unlock()
+		return
+	}
+
+	c.cancel()
+	conn := c.conn
+	c.conn = nil
+	c.mu.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+	c.notify(Closed, nil)
+}
+
+type reconnectingSubscription struct {
+	client *ReconnectingClient
+	// ctx is the context of the subscription, the resubscriptions are made with it
+	ctx     context.Context
+	methods SubscriptionMethods
+	channel interface{}
+	args    []interface{}
+
+	err chan error
+
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// subscribe subscribes on the current connection and returns the subscription with the generation of the connection
+func (s *reconnectingSubscription) subscribe(ctx context.Context) (Subscription, uint64, error) {
+	conn, gen, err := s.client.connection(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sub, err := conn.Subscribe(ctx, s.methods, s.channel, s.args...)
+	if err != nil {
+		if isConnectionError(err) && ctx.Err() == nil {
+			s.client.fail(gen, err)
+		}
+		return nil, 0, err
+	}
+
+	return sub, gen, nil
+}
+
+// forward watches the current subscription and resubscribes once its connection is replaced. The subscription ends
+// with the errors that are not due to the connection, eg: errSubscriptionOverflow, the connection is kept for the
+// other requests.
+func (s *reconnectingSubscription) forward(sub Subscription, gen uint64) {
+	stop := func() {}
+	defer func() {
+		stop()
+		close(s.err)
+	}()
+
+	for {
+		select {
+		case err, ok := <-sub.Err():
+			if !ok || err == nil {
+				// unsubscribed
+				return
+			}
+			if !isConnectionError(err) {
+				s.err <- err
+				return
+			}
+			s.client.fail(gen, err)
+		case <-s.quit:
+			sub.Unsubscribe()
+			return
+		case <-s.ctx.Done():
+			sub.Unsubscribe()
+			return
+		case <-s.client.ctx.Done():
+			s.err <- rpc.ErrClientQuit
+			return
+		}
+
+		for {
+			stop()
+			var ctx context.Context
+			ctx, stop = s.context()
+
+			var err error
+			sub, gen, err = s.subscribe(ctx)
+			if err == nil {
+				break
+			}
+
+			select {
+			case <-s.quit:
+				return
+			case <-s.ctx.Done():
+				return
+			case <-s.client.ctx.Done():
+				s.err <- rpc.ErrClientQuit
+				return
+			default:
+			}
+
+			if !isConnectionError(err) {
+				s.err <- err
+				return
+			}
+		}
+	}
+}
+
+// context returns the context of a resubscription, done once the subscription is unsubscribed, its context is done
+// or the client is closed. The context is cancelled when the resubscription is replaced.
+func (s *reconnectingSubscription) context() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(s.client.ctx)
+	go func() {
+		select {
+		case <-s.quit:
+		case <-s.ctx.Done():
+		case <-ctx.Done():
+		}
+		cancel()
+	}()
+	return ctx, cancel
+}
+
+func (s *reconnectingSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *reconnectingSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+}
@@ -0,0 +1,271 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+// errConnectionLost is the error of a connection reset by the node
+var errConnectionLost = &net.OpError{Op: "read", Net: "tcp", Err: errors.New("connection reset by peer")}
+
+type fakeSubscription struct {
+	mu     sync.Mutex
+	err    chan error
+	closed bool
+}
+
+func (s *fakeSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *fakeSubscription) Unsubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.closed = true
+		close(s.err)
+	}
+}
+
+func (s *fakeSubscription) fail(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.closed {
+		s.err <- err
+	}
+}
+
+// fakeConn is a connection that can be dropped, the notifications of its subscriptions are sent with notify
+type fakeConn struct {
+	mu       sync.Mutex
+	subs     []*fakeSubscription
+	channels []interface{}
+	dropped  bool
+}
+
+func (f *fakeConn) Call(result interface{}, method string, args ...interface{}) error {
+	return f.CallContext(context.Background(), result, method, args...)
+}
+
+func (f *fakeConn) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dropped {
+		return errConnectionLost
+	}
+
+	return json.Unmarshal([]byte(`"`+method+`"`), result)
+}
+
+func (f *fakeConn) BatchCall(b []rpc.BatchElem) error {
+	return f.BatchCallContext(context.Background(), b)
+}
+
+func (f *fakeConn) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	for i := range b {
+		b[i].Error = f.CallContext(ctx, b[i].Result, b[i].Method, b[i].Args...)
+	}
+	return nil
+}
+
+func (f *fakeConn) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dropped {
+		return nil, errConnectionLost
+	}
+
+	s := &fakeSubscription{err: make(chan error, 1)}
+	f.subs = append(f.subs, s)
+	f.channels = append(f.channels, channel)
+	return s, nil
+}
+
+func (f *fakeConn) Close() {
+	f.drop()
+}
+
+func (f *fakeConn) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dropped {
+		return
+	}
+
+	f.dropped = true
+	for _, s := range f.subs {
+		s.fail(errConnectionLost)
+	}
+}
+
+func (f *fakeConn) subscriptions() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.subs)
+}
+
+func (f *fakeConn) notify(i int, v interface{}) {
+	f.mu.Lock()
+	ch := f.channels[i]
+	f.mu.Unlock()
+	reflect.ValueOf(ch).Send(reflect.ValueOf(v))
+}
+
+type fakeDialer struct {
+	mu    sync.Mutex
+	conns []*fakeConn
+}
+
+func (d *fakeDialer) dial(ctx context.Context) (Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	c := &fakeConn{}
+	d.conns = append(d.conns, c)
+	return c, nil
+}
+
+func (d *fakeDialer) conn(i int) *fakeConn {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if i >= len(d.conns) {
+		return nil
+	}
+	return d.conns[i]
+}
+
+func TestReconnectingClient_Subscribe(t *testing.T) {
+	d := &fakeDialer{}
+	states := make(chan ConnectionState, 10)
+	c, err := NewReconnectingClient(context.Background(), d.dial, ReconnectConfig{
+		MinBackoff: time.Millisecond,
+		OnStateChange: func(state ConnectionState, err error) {
+			states <- state
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, Connected, <-states)
+
+	heads := make(chan string, 1)
+	sub, err := c.Subscribe(context.Background(), NewHeadSubscription, heads)
+	assert.NoError(t, err)
+	d.conn(0).notify(0, "0x01")
+	assert.Equal(t, "0x01", <-heads)
+
+	// the subscription is re-established on the new connection
+	d.conn(0).drop()
+	assert.Equal(t, Disconnected, <-states)
+	assert.Equal(t, Connected, <-states)
+	assert.Eventually(t, func() bool {
+		return d.conn(1) != nil && d.conn(1).subscriptions() == 1
+	}, time.Second, time.Millisecond)
+	d.conn(1).notify(0, "0x02")
+	assert.Equal(t, "0x02", <-heads)
+
+	var res string
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.Equal(t, "system_name", res)
+
+	sub.Unsubscribe()
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+
+	c.Close()
+	assert.Equal(t, Closed, <-states)
+	assert.Equal(t, rpc.ErrClientQuit, c.Call(&res, "system_name"))
+}
+
+func TestReconnectingClient_Subscribe_Context(t *testing.T) {
+	d := &fakeDialer{}
+	c, err := NewReconnectingClient(context.Background(), d.dial, ReconnectConfig{MinBackoff: time.Millisecond})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the resubscriptions end with the context of the subscription
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.Subscribe(ctx, NewHeadSubscription, make(chan string))
+	assert.NoError(t, err)
+	d.conn(0).drop()
+	assert.Eventually(t, func() bool {
+		return d.conn(1) != nil && d.conn(1).subscriptions() == 1
+	}, time.Second, time.Millisecond)
+	cancel()
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+	s := d.conn(1).subs[0]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	assert.True(t, s.closed)
+}
+
+func TestReconnectingClient_SubscriptionError(t *testing.T) {
+	d := &fakeDialer{}
+	c, err := NewReconnectingClient(context.Background(), d.dial, ReconnectConfig{MinBackoff: time.Millisecond})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	slow, err := c.Subscribe(context.Background(), NewHeadSubscription, make(chan string))
+	assert.NoError(t, err)
+	heads := make(chan string, 1)
+	_, err = c.Subscribe(context.Background(), NewHeadSubscription, heads)
+	assert.NoError(t, err)
+
+	// only the failed subscription ends, the connection and the other subscriptions are kept
+	d.conn(0).subs[0].fail(errSubscriptionOverflow)
+	assert.Equal(t, errSubscriptionOverflow, <-slow.Err())
+	_, ok := <-slow.Err()
+	assert.False(t, ok)
+	d.conn(0).notify(1, "0x01")
+	assert.Equal(t, "0x01", <-heads)
+	var res string
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.Nil(t, d.conn(1))
+	assert.Equal(t, 2, d.conn(0).subscriptions())
+}
+
+func TestReconnectingClient_CallFailure(t *testing.T) {
+	d := &fakeDialer{}
+	c, err := NewReconnectingClient(context.Background(), d.dial, ReconnectConfig{MinBackoff: time.Millisecond})
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the failed call is not retried but the following calls use the new connection
+	d.conn(0).dropped = true
+	var res string
+	assert.Error(t, c.Call(&res, "system_name"))
+	assert.NoError(t, c.Call(&res, "system_name"))
+	assert.NotNil(t, d.conn(1))
+
+	// calls wait for the reconnect until their context is done
+	c.mu.Lock()
+	c.conn = nil
+	c.ready = make(chan struct{})
+	c.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, c.CallContext(ctx, &res, "system_name"))
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, err := range []error{io.EOF, io.ErrUnexpectedEOF, rpc.ErrClientQuit, ErrInjectedDisconnect, errConnectionLost,
+		&net.OpError{Op: "write", Net: "tcp", Err: errors.New("use of closed network connection")},
+		fmt.Errorf("subscription ended: %w", io.EOF)} {
+		assert.True(t, isConnectionError(err), err.Error())
+	}
+
+	for _, err := range []error{nil, context.Canceled, context.DeadlineExceeded, errors.New("method not found"),
+		&jsonError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction"}, errSubscriptionOverflow,
+		&json.SyntaxError{}} {
+		assert.False(t, isConnectionError(err))
+	}
+}
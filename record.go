@@ -0,0 +1,497 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Fixture is the RPC traffic captured by a Recorder, in the order of the requests
+type Fixture struct {
+	Calls         []RecordedCall         `json:"calls"`
+	Subscriptions []RecordedSubscription `json:"subscriptions"`
+}
+
+// RecordedCall is a request and its response, the requests of a batch are recorded as single calls
+type RecordedCall struct {
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *RecordedError  `json:"error,omitempty"`
+}
+
+// RecordedSubscription is a subscription with the notifications received until it ended
+type RecordedSubscription struct {
+	// Method is the subscribe method
+	Method        string            `json:"method"`
+	Params        json.RawMessage   `json:"params"`
+	Notifications []json.RawMessage `json:"notifications"`
+	// Error is the error of the subscribe request
+	Error *RecordedError `json:"error,omitempty"`
+	// Ended is the error that ended the subscription
+	Ended *RecordedError `json:"ended,omitempty"`
+}
+
+// RecordedError is an error returned by the node, Code is 0 for errors without an RPC error code
+type RecordedError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RecordedError) Error() string {
+	return e.Message
+}
+
+func (e *RecordedError) ErrorCode() int {
+	return e.Code
+}
+
+// err returns the error with its code, if any, so that it's handled like the recorded error
+func (e *RecordedError) err() error {
+	if e.Code == 0 {
+		return fmt.Errorf("%s", e.Message)
+	}
+	return e
+}
+
+func recordError(err error) *RecordedError {
+	if err == nil {
+		return nil
+	}
+
+	re := &RecordedError{Message: err.Error()}
+	if rerr, ok := err.(rpc.Error); ok {
+		re.Code = rerr.ErrorCode()
+	}
+	return re
+}
+
+// recordParams encodes the arguments of a request, identifying the request on replay
+func recordParams(args []interface{}) (json.RawMessage, error) {
+	if len(args) == 0 {
+		return json.RawMessage("[]"), nil
+	}
+	return json.Marshal(args)
+}
+
+// LoadFixture reads a fixture saved with Fixture.Save
+func LoadFixture(path string) (*Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f Fixture
+	err = json.Unmarshal(b, &f)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %v", path, err)
+	}
+	return &f, nil
+}
+
+// Save writes the fixture as JSON
+func (f *Fixture) Save(path string) error {
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// Recorder is a Client recording the requests, the responses and the subscription notifications of the wrapped
+// client, eg: of a real node, to replay them with NewReplayClient
+type Recorder struct {
+	client Client
+
+	mu      sync.Mutex
+	fixture Fixture
+}
+
+// NewRecorder records the traffic of the client
+func NewRecorder(client Client) *Recorder {
+	return &Recorder{client: client}
+}
+
+// Fixture returns a copy of the traffic recorded so far
+func (r *Recorder) Fixture() *Fixture {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	f := &Fixture{
+		Calls:         append([]RecordedCall{}, r.fixture.Calls...),
+		Subscriptions: make([]RecordedSubscription, len(r.fixture.Subscriptions)),
+	}
+	for i, s := range r.fixture.Subscriptions {
+		s.Notifications = append([]json.RawMessage{}, s.Notifications...)
+		f.Subscriptions[i] = s
+	}
+	return f
+}
+
+// Save writes the traffic recorded so far to the file
+func (r *Recorder) Save(path string) error {
+	return r.Fixture().Save(path)
+}
+
+func (r *Recorder) record(c RecordedCall) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fixture.Calls = append(r.fixture.Calls, c)
+}
+
+func (r *Recorder) Call(result interface{}, method string, args ...interface{}) error {
+	return r.CallContext(context.Background(), result, method, args...)
+}
+
+func (r *Recorder) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	params, err := recordParams(args)
+	if err != nil {
+		return err
+	}
+
+	var raw json.RawMessage
+	err = r.client.CallContext(ctx, &raw, method, args...)
+	if err != nil {
+		// the cancellation is not a response of the node
+		if ctx.Err() == nil {
+			r.record(RecordedCall{Method: method, Params: params, Error: recordError(err)})
+		}
+		return err
+	}
+
+	r.record(RecordedCall{Method: method, Params: params, Result: raw})
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, result)
+}
+
+func (r *Recorder) BatchCall(b []rpc.BatchElem) error {
+	return r.BatchCallContext(context.Background(), b)
+}
+
+func (r *Recorder) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	params := make([]json.RawMessage, len(b))
+	raw := make([]json.RawMessage, len(b))
+	batch := make([]rpc.BatchElem, len(b))
+	for i, e := range b {
+		var err error
+		params[i], err = recordParams(e.Args)
+		if err != nil {
+			return err
+		}
+		batch[i] = rpc.BatchElem{Method: e.Method, Args: e.Args, Result: &raw[i]}
+	}
+
+	err := r.client.BatchCallContext(ctx, batch)
+	if err != nil {
+		return err
+	}
+
+	for i, e := range batch {
+		c := RecordedCall{Method: e.Method, Params: params[i], Result: raw[i], Error: recordError(e.Error)}
+		if e.Error != nil {
+			c.Result = nil
+			b[i].Error = e.Error
+		} else if b[i].Result != nil {
+			b[i].Error = json.Unmarshal(raw[i], b[i].Result)
+		}
+		r.record(c)
+	}
+	return nil
+}
+
+func (r *Recorder) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	params, err := recordParams(args)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("invalid channel %T", channel)
+	}
+
+	raw := make(chan json.RawMessage)
+	sub, err := r.client.Subscribe(ctx, methods, raw, args...)
+	if err != nil {
+		if ctx.Err() == nil {
+			r.mu.Lock()
+			r.fixture.Subscriptions = append(r.fixture.Subscriptions,
+				RecordedSubscription{Method: methods.Subscribe, Params: params, Error: recordError(err)})
+			r.mu.Unlock()
+		}
+		return nil, err
+	}
+
+	r.mu.Lock()
+	idx := len(r.fixture.Subscriptions)
+	r.fixture.Subscriptions = append(r.fixture.Subscriptions, RecordedSubscription{Method: methods.Subscribe, Params: params})
+	r.mu.Unlock()
+
+	rs := &forwardingSubscription{err: make(chan error, 1), quit: make(chan struct{})}
+	go rs.forward(sub, raw, ch, func(n json.RawMessage) (json.RawMessage, error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		s := &r.fixture.Subscriptions[idx]
+		s.Notifications = append(s.Notifications, n)
+		return n, nil
+	}, func(err error) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.fixture.Subscriptions[idx].Ended = recordError(err)
+	})
+	return rs, nil
+}
+
+func (r *Recorder) Close() {
+	r.client.Close()
+}
+
+// forwardingSubscription decodes the raw notifications of a subscription in to the channel of the subscriber
+type forwardingSubscription struct {
+	err chan error
+
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+// forward sends the notifications to the channel until the subscription ends. Every notification is passed to
+// notified, which returns the notification to send or an error ending the subscription, and ended is called with
+// the error ending the subscription.
+func (s *forwardingSubscription) forward(sub Subscription, raw <-chan json.RawMessage, ch reflect.Value,
+	notified func(json.RawMessage) (json.RawMessage, error), ended func(error)) {
+	defer close(s.err)
+
+	for {
+		select {
+		case n := <-raw:
+			n, err := notified(n)
+			if err != nil {
+				sub.Unsubscribe()
+				s.err <- err
+				return
+			}
+
+			v := reflect.New(ch.Type().Elem())
+			err = json.Unmarshal(n, v.Interface())
+			if err != nil {
+				sub.Unsubscribe()
+				s.err <- err
+				return
+			}
+
+			if !s.send(ch, v.Elem()) {
+				sub.Unsubscribe()
+				return
+			}
+		case err, ok := <-sub.Err():
+			if ok && err != nil {
+				ended(err)
+				s.err <- err
+			}
+			return
+		case <-s.quit:
+			sub.Unsubscribe()
+			return
+		}
+	}
+}
+
+// send blocks until the value is sent or the subscription is unsubscribed
+func (s *forwardingSubscription) send(ch, v reflect.Value) bool {
+	chosen, _, _ := reflect.Select([]reflect.SelectCase{
+		{Dir: reflect.SelectSend, Chan: ch, Send: v},
+		{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(s.quit)},
+	})
+	return chosen == 0
+}
+
+func (s *forwardingSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *forwardingSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+}
+
+// NotRecordedError is returned by the replay client for a request missing in the fixture
+type NotRecordedError struct {
+	Method string
+	Params string
+}
+
+func (e *NotRecordedError) Error() string {
+	return fmt.Sprintf("no recorded response for %s %s", e.Method, e.Params)
+}
+
+// replayClient serves the responses of a fixture
+type replayClient struct {
+	mu sync.Mutex
+	// calls and subscriptions by request, the responses to the same request are served in the recorded order
+	calls         map[string][]RecordedCall
+	subscriptions map[string][]RecordedSubscription
+}
+
+// NewReplayClient returns a client serving the recorded responses of the fixture to the same requests. The
+// responses to the same request are served in the recorded order and the last one is repeated once they are all
+// served, a request that wasn't recorded fails with a NotRecordedError. The notifications of a subscription are
+// sent at once, the subscription then stays open unless it ended with an error.
+func NewReplayClient(f *Fixture) Client {
+	c := &replayClient{
+		calls:         make(map[string][]RecordedCall),
+		subscriptions: make(map[string][]RecordedSubscription),
+	}
+	for _, rc := range f.Calls {
+		key := requestKey(rc.Method, rc.Params)
+		c.calls[key] = append(c.calls[key], rc)
+	}
+	for _, rs := range f.Subscriptions {
+		key := requestKey(rs.Method, rs.Params)
+		c.subscriptions[key] = append(c.subscriptions[key], rs)
+	}
+	return c
+}
+
+// requestKey identifies the request regardless of the formatting of the params in the fixture
+func requestKey(method string, params json.RawMessage) string {
+	var bb bytes.Buffer
+	if json.Compact(&bb, params) != nil {
+		return method + string(params)
+	}
+	return method + bb.String()
+}
+
+func (c *replayClient) next(method string, params json.RawMessage) (RecordedCall, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := requestKey(method, params)
+	calls := c.calls[key]
+	if len(calls) == 0 {
+		return RecordedCall{}, &NotRecordedError{Method: method, Params: string(params)}
+	}
+
+	if len(calls) > 1 {
+		c.calls[key] = calls[1:]
+	}
+	return calls[0], nil
+}
+
+func (c *replayClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *replayClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	params, err := recordParams(args)
+	if err != nil {
+		return err
+	}
+
+	rc, err := c.next(method, params)
+	if err != nil {
+		return err
+	}
+
+	if rc.Error != nil {
+		return rc.Error.err()
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(rc.Result, result)
+}
+
+func (c *replayClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+func (c *replayClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	for i := range b {
+		b[i].Error = c.CallContext(ctx, b[i].Result, b[i].Method, b[i].Args...)
+	}
+	return nil
+}
+
+func (c *replayClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	params, err := recordParams(args)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("invalid channel %T", channel)
+	}
+
+	c.mu.Lock()
+	key := requestKey(methods.Subscribe, params)
+	subs := c.subscriptions[key]
+	if len(subs) > 1 {
+		c.subscriptions[key] = subs[1:]
+	}
+	c.mu.Unlock()
+	if len(subs) == 0 {
+		return nil, &NotRecordedError{Method: methods.Subscribe, Params: string(params)}
+	}
+
+	rs := subs[0]
+	if rs.Error != nil {
+		return nil, rs.Error.err()
+	}
+
+	raw := make(chan json.RawMessage)
+	recorded := &recordedSubscription{err: make(chan error, 1), quit: make(chan struct{})}
+	go func() {
+		for _, n := range rs.Notifications {
+			select {
+			case raw <- n:
+			case <-recorded.quit:
+				return
+			}
+		}
+		if rs.Ended != nil {
+			recorded.err <- rs.Ended.err()
+		}
+	}()
+
+	sub := &forwardingSubscription{err: make(chan error, 1), quit: make(chan struct{})}
+	go sub.forward(recorded, raw, ch, func(n json.RawMessage) (json.RawMessage, error) {
+		return n, nil
+	}, func(error) {})
+	return sub, nil
+}
+
+func (c *replayClient) Close() {}
+
+// recordedSubscription ends with the recorded error or once unsubscribed
+type recordedSubscription struct {
+	err chan error
+
+	quit     chan struct{}
+	quitOnce sync.Once
+}
+
+func (s *recordedSubscription) Err() <-chan error {
+	return s.err
+}
+
+func (s *recordedSubscription) Unsubscribe() {
+	s.quitOnce.Do(func() {
+		close(s.quit)
+	})
+}
@@ -0,0 +1,131 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestRecorder_Replay(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	rec := NewRecorder(c)
+	heads := make(chan Header)
+	sub, err := rec.Subscribe(ctx, NewHeadSubscription, heads)
+	assert.NoError(t, err)
+
+	a := newTestAuthor(t, rec)
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	a.nonces.Set(0)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, substratetest.ErrCodeAlreadyImported, err)
+	n.ProduceBlock()
+	head := <-heads
+	sub.Unsubscribe()
+
+	n.SetStorage([]byte{1}, []byte{2})
+	n.ProduceBlock()
+	meta, err := NewStateRPC(rec).MetaData(ctx, nil)
+	assert.NoError(t, err)
+	values, err := NewStateRPC(rec).StorageMulti(ctx, [][]byte{{1}, {2}}, nil)
+	assert.NoError(t, err)
+	block, err := NewChainRPC(rec).Block(ctx, head.Hash(), meta)
+	assert.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	assert.NoError(t, rec.Save(path))
+	f, err := LoadFixture(path)
+	assert.NoError(t, err)
+	n.Close()
+
+	replay := NewReplayClient(f)
+	rheads := make(chan Header)
+	sub, err = replay.Subscribe(ctx, NewHeadSubscription, rheads)
+	assert.NoError(t, err)
+	assert.Equal(t, head, <-rheads)
+	sub.Unsubscribe()
+
+	a = newTestAuthor(t, replay)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	a.nonces.Set(0)
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assertPoolError(t, substratetest.ErrCodeAlreadyImported, err)
+
+	rmeta, err := NewStateRPC(replay).MetaData(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, meta, rmeta)
+	rvalues, err := NewStateRPC(replay).StorageMulti(ctx, [][]byte{{1}, {2}}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, values, rvalues)
+	rblock, err := NewChainRPC(replay).Block(ctx, head.Hash(), rmeta)
+	assert.NoError(t, err)
+	assert.Equal(t, block, rblock)
+
+	_, err = NewChainRPC(replay).Header(ctx, nil)
+	assert.IsType(t, &NotRecordedError{}, err)
+}
+
+func TestReplayClient_SubscriptionEnded(t *testing.T) {
+	f := &Fixture{Subscriptions: []RecordedSubscription{
+		{Method: "chain_subscribeNewHead", Params: []byte(`[]`), Notifications: []json.RawMessage{[]byte(`{"number":"0x1"}`)},
+			Ended: &RecordedError{Message: rpc.ErrClientQuit.Error()}},
+		{Method: "grandpa_subscribeJustifications", Params: []byte(`[]`), Error: &RecordedError{Code: -32601, Message: "not found"}},
+	}}
+
+	c := NewReplayClient(f)
+	heads := make(chan Header)
+	sub, err := c.Subscribe(context.Background(), NewHeadSubscription, heads)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), (<-heads).Number)
+	assert.EqualError(t, <-sub.Err(), rpc.ErrClientQuit.Error())
+
+	_, err = c.Subscribe(context.Background(), JustificationSubscription, make(chan string))
+	assert.Equal(t, -32601, err.(rpc.Error).ErrorCode())
+}
+
+// testdata/chain_v12.json was recorded from substratetest.Node serving metadata v12 with the signed extensions, its
+// block holds a Kerplunk.commit extrinsic signed by //Alice
+func TestReplayClient_Fixture(t *testing.T) {
+	f, err := LoadFixture(filepath.Join("testdata", "chain_v12.json"))
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	replay := NewReplayClient(f)
+	meta, err := NewStateRPC(replay).MetaData(ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(12), meta.Version)
+	assert.Equal(t, testSignedExtensions, meta.Extrinsic.SignedExtensions)
+
+	hash, err := hexutil.Decode("0x4d985fd04589ebc47488ed8c5a07000143a0a4e617ef91720b5339b23a13809d")
+	assert.NoError(t, err)
+	chain := NewChainRPC(replay)
+	chain.TypeRegistry().RegisterCall("Kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(ctx, hash, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), b.Block.Header.Number)
+	assert.Len(t, b.Block.Extrinsics, 1)
+	e := b.Block.Extrinsics[0]
+	assert.Equal(t, AlicePubKey, hexutil.Encode(e.Signature.Signer.PubKey[:]))
+	assert.Equal(t, meta.Metadata.MethodIndex("Kerplunk.commit"), e.Method.CallIndex)
+	_, ok := e.Method.Args.(*testAnchorParams)
+	assert.True(t, ok)
+
+	_, err = chain.Block(ctx, nil, meta)
+	assert.IsType(t, &NotRecordedError{}, err)
+}
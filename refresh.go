@@ -0,0 +1,113 @@
+package substrate
+
+import (
+	"context"
+)
+
+// NewAuthor submits the extrinsics of the account to the chain of the node. The genesis hash, the metadata and the
+// runtime version of the finalized block are fetched from the node and the nonce is synchronised from the chain on
+// first use. The extrinsics are signed with the format version of the metadata from v11. Run KeepFresh to follow the finalized blocks and the runtime upgrades.
+func NewAuthor(ctx context.Context, client Client, account Account) (*Author, error) {
+	genesis, err := NewChainRPC(client).BlockHash(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	a := NewAuthorRPC(0, genesis, account, *NewMetadataVersioned(), client)
+	a.nonces.invalidate()
+	err = a.Refresh(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Refresh sets the finalized head as the reference block of the mortal eras and fetches its runtime version, the
+// metadata is fetched again if the spec version changed
+func (a *Author) Refresh(ctx context.Context) error {
+	chain := NewChainRPC(a.client)
+	hash, err := chain.FinalizedHead(ctx)
+	if err != nil {
+		return err
+	}
+
+	h, err := chain.Header(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	return a.refresh(ctx, h)
+}
+
+// KeepFresh subscribes to the finalized heads and refreshes the Author with every head until the context is
+// cancelled or the subscription fails
+func (a *Author) KeepFresh(ctx context.Context) error {
+	heads := make(chan *Header, 16)
+	sub, err := a.client.Subscribe(ctx, FinalizedHeadSubscription, heads)
+	if err != nil {
+		return err
+	}
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case h := <-heads:
+			err = a.refresh(ctx, h)
+		case err = <-sub.Err():
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// refresh sets the finalized header as the reference block, the older headers are ignored
+func (a *Author) refresh(ctx context.Context, h *Header) error {
+	a.mu.RLock()
+	prev := a.finalized
+	specVersion := a.specVersion
+	a.mu.RUnlock()
+	if prev != nil && h.Number < prev.Number {
+		return nil
+	}
+
+	state := NewStateRPC(a.client)
+	hash := h.Hash()
+	v, err := state.RuntimeVersion(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	// the metadata only changes with the runtime
+	var meta *MetadataVersioned
+	if prev == nil || v.SpecVersion != specVersion {
+		meta, err = state.MetaData(ctx, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.finalized != nil && h.Number < a.finalized.Number {
+		return nil
+	}
+
+	a.finalized = h
+	a.specVersion = v.SpecVersion
+	a.transactionVersion = v.TransactionVersion
+	if meta != nil {
+		a.meta = *meta
+		a.nonces.setMetadata(meta)
+		// the format of the extrinsics is described from v11, the version of the Author is kept before
+		if meta.Version >= 11 {
+			a.version = meta.Extrinsic.Version & ExtrinsicUnmaskVersion
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestNewAuthor(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	var alice [32]byte
+	copy(alice[:], pair.PublicKey())
+	n.SetNonce(alice, 3)
+	n.SetRuntimeVersion(substratetest.RuntimeVersion{SpecName: "node", SpecVersion: 5, TransactionVersion: 2})
+	for i := 0; i < 5; i++ {
+		n.ProduceBlock()
+	}
+
+	ctx := context.Background()
+	a, err := NewAuthor(ctx, c, NewKeyringAccount(pair))
+	assert.NoError(t, err)
+	assert.Equal(t, n.BlockHash(0), a.genesisHash)
+	assert.Equal(t, Hash(n.BlockHash(5)), a.finalized.Hash())
+	assert.Equal(t, uint32(5), a.specVersion)
+	assert.Equal(t, uint32(2), a.transactionVersion)
+	assert.Equal(t, testMetadata(t).Metadata.MethodIndex("kerplunk.commit"), a.meta.Metadata.MethodIndex("kerplunk.commit"))
+
+	// the mortal era is built from the finalized block and the nonce from the chain
+	for i := 0; i < 3; i++ {
+		n.ProduceBlock()
+	}
+	a.SetMortality(64)
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	block := n.ProduceBlock()
+
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(ctx, block, testMetadata(t))
+	assert.NoError(t, err)
+	assert.Len(t, b.Block.Extrinsics, 1)
+	e := b.Block.Extrinsics[0]
+	assert.Equal(t, uint64(3), e.Nonce)
+	assert.Equal(t, MortalEra{Period: 64, Phase: 5}, e.Era.AsMortalEra)
+	e.BestKnownBlock = n.BlockHash(5)
+	assert.True(t, pair.Verify(e.SignaturePayload(), e.Signature.Signature.Hash[:]))
+
+	n.Close()
+	_, err = NewAuthor(ctx, c, NewKeyringAccount(pair))
+	assert.Error(t, err)
+}
+
+func TestAuthor_KeepFresh(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	a, err := NewAuthor(ctx, c, NewKeyringAccount(pair))
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(ExtrinsicVersion1), a.version)
+	done := make(chan error)
+	go func() {
+		done <- a.KeepFresh(ctx)
+	}()
+
+	// the runtime upgrade adds signed extensions to the metadata
+	n.SetSignedExtensions(testSignedExtensions...)
+	n.SetMetadata(encodeTestMetadata(t, 11, testSignedExtensions))
+	n.SetRuntimeVersion(substratetest.RuntimeVersion{SpecName: "node", SpecVersion: 2, TransactionVersion: 3})
+	assert.Eventually(t, func() bool {
+		n.ProduceBlock()
+		a.mu.RLock()
+		defer a.mu.RUnlock()
+		return a.specVersion == 2 && len(a.meta.Extrinsic.SignedExtensions) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+	a.mu.RLock()
+	assert.Equal(t, uint8(ExtrinsicVersion4), a.version)
+	a.mu.RUnlock()
+
+	a1, _ := testAnchorCall()
+	_, err = a.SubmitExtrinsic(ctx, "Kerplunk.commit", a1)
+	assert.NoError(t, err)
+	block := n.ProduceBlock()
+
+	meta := testMetadata(t)
+	meta.Extrinsic.SignedExtensions = testSignedExtensions
+	chain := NewChainRPC(c)
+	chain.TypeRegistry().RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	b, err := chain.Block(ctx, block, meta)
+	assert.NoError(t, err)
+	assert.Len(t, b.Block.Extrinsics, 1)
+	e := b.Block.Extrinsics[0]
+	e.SpecVersion = 2
+	e.TransactionVersion = 3
+	e.GenesisHash = n.BlockHash(0)
+	e.BestKnownBlock = n.BlockHash(0)
+	assert.True(t, pair.Verify(e.SignaturePayload(), e.Signature.Signature.Hash[:]))
+
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+}
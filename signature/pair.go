@@ -0,0 +1,132 @@
+package signature
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ChainSafe/go-schnorrkel"
+	subkey "github.com/vedhavyas/go-subkey"
+	subed25519 "github.com/vedhavyas/go-subkey/ed25519"
+	"github.com/vedhavyas/go-subkey/sr25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+// SubstrateNetwork is the SS58 address prefix of the generic substrate network
+const SubstrateNetwork = 42
+
+// ErrLocked is returned when signing with a locked pair
+var ErrLocked = errors.New("keyring pair is locked")
+
+// keyringPair signs in process with the secret key derived from a SURI
+type keyringPair struct {
+	tp  SupportedKeyType
+	pub []byte
+
+	mu   sync.RWMutex
+	kp   subkey.KeyPair
+	meta map[string]interface{}
+}
+
+// KeyringPairFromURI derives the pair of the given type from a SURI, eg: "//Alice" or
+// "<phrase>//hard/soft///password"
+func KeyringPairFromURI(suri string, tp SupportedKeyType) (KeyringPair, error) {
+	var scheme subkey.Scheme
+	switch tp {
+	case ED25519:
+		scheme = subed25519.Scheme{}
+	case SR25519:
+		scheme = sr25519.Scheme{}
+	default:
+		return nil, fmt.Errorf("unsupported key type %d", tp)
+	}
+
+	kp, err := subkey.DeriveKeyPair(scheme, suri)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyringPair{tp: tp, pub: kp.Public(), kp: kp, meta: make(map[string]interface{})}, nil
+}
+
+func (p *keyringPair) Type() SupportedKeyType {
+	return p.tp
+}
+
+// SS58Address encodes the account ID as a SS58 address of the SubstrateNetwork
+func SS58Address(accountID []byte) (string, error) {
+	return subkey.SS58Address(accountID, SubstrateNetwork)
+}
+
+func (p *keyringPair) Address() string {
+	addr, err := SS58Address(p.pub)
+	if err != nil {
+		// the public key always has the length of an account ID
+		panic(err)
+	}
+	return addr
+}
+
+func (p *keyringPair) Meta() map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.meta
+}
+
+func (p *keyringPair) SetMeta(meta map[string]interface{}) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.meta = meta
+}
+
+func (p *keyringPair) IsLocked() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.kp == nil
+}
+
+func (p *keyringPair) Lock() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.kp = nil
+}
+
+func (p *keyringPair) PublicKey() []byte {
+	return p.pub
+}
+
+func (p *keyringPair) Sign(message []byte) ([]byte, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.kp == nil {
+		return nil, ErrLocked
+	}
+	return p.kp.Sign(message)
+}
+
+func (p *keyringPair) Verify(message []byte, signature []byte) bool {
+	switch p.tp {
+	case ED25519:
+		return len(signature) == ed25519.SignatureSize && ed25519.Verify(p.pub, message, signature)
+	case SR25519:
+		var pb [schnorrkel.PublicKeySize]byte
+		var sb [schnorrkel.SignatureSize]byte
+		if len(signature) != len(sb) {
+			return false
+		}
+		copy(pb[:], p.pub)
+		copy(sb[:], signature)
+
+		pub, err := schnorrkel.NewPublicKey(pb)
+		if err != nil {
+			return false
+		}
+		sig := &schnorrkel.Signature{}
+		if sig.Decode(sb) != nil {
+			return false
+		}
+		ok, err := pub.Verify(sig, schnorrkel.NewSigningContext([]byte("substrate"), message))
+		return err == nil && ok
+	}
+	return false
+}
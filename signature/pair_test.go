@@ -0,0 +1,45 @@
+package signature
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyringPairFromURI(t *testing.T) {
+	alice, err := KeyringPairFromURI("//Alice", SR25519)
+	assert.NoError(t, err)
+	assert.Equal(t, "d43593c715fdd31c61141abd04a99fd6822c8558854ccde39a5684e7a56da27d", hex.EncodeToString(alice.PublicKey()))
+	assert.Equal(t, "5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY", alice.Address())
+
+	for _, tp := range []SupportedKeyType{ED25519, SR25519} {
+		pair, err := KeyringPairFromURI(DEV_PHRASE+"//Bob", tp)
+		assert.NoError(t, err)
+		assert.Equal(t, tp, pair.Type())
+		sig, err := pair.Sign([]byte("message"))
+		assert.NoError(t, err)
+		assert.Len(t, sig, 64)
+		assert.True(t, pair.Verify([]byte("message"), sig))
+		assert.False(t, pair.Verify([]byte("other"), sig))
+
+		pair.Lock()
+		assert.True(t, pair.IsLocked())
+		_, err = pair.Sign([]byte("message"))
+		assert.Equal(t, ErrLocked, err)
+		assert.True(t, pair.Verify([]byte("message"), sig))
+	}
+
+	_, err = KeyringPairFromURI("//Alice", 0)
+	assert.Error(t, err)
+	_, err = KeyringPairFromURI("not a/phrase", SR25519)
+	assert.Error(t, err)
+}
+
+func TestKeyring_AddFromURI(t *testing.T) {
+	kr := &Keyring{}
+	pair, err := kr.AddFromURI("//Alice", map[string]interface{}{"name": "alice"}, SR25519)
+	assert.NoError(t, err)
+	assert.Equal(t, pair, kr.Pairs["5GrwvaEF5zXb26Fz9rcQpDWS57CtERHpNehXCPcNoHGKutQY"])
+	assert.Equal(t, "alice", pair.Meta()["name"])
+}
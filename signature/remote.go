@@ -0,0 +1,152 @@
+package signature
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/crypto/blake2b"
+)
+
+// SignRequest is the body of a request to a remote signer
+type SignRequest struct {
+	// KeyID identifies the key of the signer, eg: its SS58 address
+	KeyID   string        `json:"keyId"`
+	Payload hexutil.Bytes `json:"payload"`
+}
+
+// SignResponse is the body of the response of a remote signer, Error is set if the payload wasn't signed
+type SignResponse struct {
+	Signature hexutil.Bytes `json:"signature,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// AuditRecord is the record of a request to a remote signer
+type AuditRecord struct {
+	Time  time.Time
+	KeyID string
+	// PayloadHash is the blake2b-256 hash of the signed payload
+	PayloadHash []byte
+	Signature   []byte
+	Duration    time.Duration
+	Err         error
+}
+
+// RemoteSignerConfig configures a remote signer
+type RemoteSignerConfig struct {
+	// URL of the signer, the requests are POSTed to the URL
+	URL   string
+	KeyID string
+	// Timeout of a request, 10s by default
+	Timeout time.Duration
+	// Client sends the requests, http.DefaultClient by default
+	Client *http.Client
+	// Audit is passed the record of every request when set
+	Audit func(record AuditRecord)
+}
+
+type remoteSigner struct {
+	config RemoteSignerConfig
+}
+
+// NewRemoteSigner signs with a signer out of process over HTTP, the payload is sent in a SignRequest and the
+// signature is returned in a SignResponse
+func NewRemoteSigner(config RemoteSignerConfig) Signer {
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+	return &remoteSigner{config: config}
+}
+
+func (s *remoteSigner) Sign(ctx context.Context, payload []byte) (sig []byte, err error) {
+	if s.config.Audit != nil {
+		start := time.Now()
+		h := blake2b.Sum256(payload)
+		defer func() {
+			s.config.Audit(AuditRecord{Time: start, KeyID: s.config.KeyID, PayloadHash: h[:], Signature: sig,
+				Duration: time.Since(start), Err: err})
+		}()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	body, err := json.Marshal(SignRequest{KeyID: s.config.KeyID, Payload: payload})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.config.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var res SignResponse
+	err = json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&res)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response of the signer, status %d: %v", resp.StatusCode, err)
+	}
+
+	if resp.StatusCode != http.StatusOK || res.Error != "" {
+		return nil, fmt.Errorf("signer failed with status %d: %s", resp.StatusCode, res.Error)
+	}
+	if len(res.Signature) == 0 {
+		return nil, fmt.Errorf("signer returned no signature")
+	}
+	return res.Signature, nil
+}
+
+// NewSignerHandler serves the signers by key ID over HTTP, eg: as a stand-in of the remote signer in tests
+func NewSignerHandler(signers map[string]Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		respond := func(status int, res SignResponse) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(res)
+		}
+
+		if r.Method != http.MethodPost {
+			respond(http.StatusMethodNotAllowed, SignResponse{Error: "method not allowed"})
+			return
+		}
+
+		var req SignRequest
+		b, err := ioutil.ReadAll(io.LimitReader(r.Body, 1<<20))
+		if err == nil {
+			err = json.Unmarshal(b, &req)
+		}
+		if err != nil {
+			respond(http.StatusBadRequest, SignResponse{Error: err.Error()})
+			return
+		}
+
+		signer, ok := signers[req.KeyID]
+		if !ok {
+			respond(http.StatusNotFound, SignResponse{Error: fmt.Sprintf("unknown key %s", req.KeyID)})
+			return
+		}
+
+		sig, err := signer.Sign(r.Context(), req.Payload)
+		if err != nil {
+			respond(http.StatusInternalServerError, SignResponse{Error: err.Error()})
+			return
+		}
+		respond(http.StatusOK, SignResponse{Signature: sig})
+	})
+}
@@ -0,0 +1,54 @@
+package signature
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoteSigner(t *testing.T) {
+	alice, err := KeyringPairFromURI("//Alice", SR25519)
+	assert.NoError(t, err)
+	server := httptest.NewServer(NewSignerHandler(map[string]Signer{alice.Address(): NewKeyringSigner(alice)}))
+	defer server.Close()
+
+	var records []AuditRecord
+	s := NewRemoteSigner(RemoteSignerConfig{URL: server.URL, KeyID: alice.Address(), Audit: func(r AuditRecord) {
+		records = append(records, r)
+	}})
+	sig, err := s.Sign(context.Background(), []byte("payload"))
+	assert.NoError(t, err)
+	assert.True(t, alice.Verify([]byte("payload"), sig))
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, alice.Address(), records[0].KeyID)
+		assert.Equal(t, sig, records[0].Signature)
+	}
+
+	s = NewRemoteSigner(RemoteSignerConfig{URL: server.URL, KeyID: "bob"})
+	_, err = s.Sign(context.Background(), []byte("payload"))
+	assert.EqualError(t, err, "signer failed with status 404: unknown key bob")
+}
+
+func TestRemoteSigner_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-done
+	}))
+	defer server.Close()
+	defer close(done)
+
+	var records []AuditRecord
+	s := NewRemoteSigner(RemoteSignerConfig{URL: server.URL, Timeout: 10 * time.Millisecond, Audit: func(r AuditRecord) {
+		records = append(records, r)
+	}})
+	_, err := s.Sign(context.Background(), []byte("payload"))
+	assert.Error(t, err)
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, err, records[0].Err)
+		assert.True(t, records[0].Duration >= 10*time.Millisecond)
+	}
+}
@@ -2,7 +2,6 @@ package signature
 
 import (
 	"errors"
-	"golang.org/x/crypto/ed25519"
 	"regexp"
 )
 
@@ -12,6 +11,7 @@ type SupportedKeyType int
 
 const (
 	ED25519 SupportedKeyType = iota + 1
+	SR25519
 )
 
 type Keyring struct {
@@ -19,29 +19,34 @@ type Keyring struct {
 	Pairs map[string]KeyringPair
 }
 
-func (kr *Keyring) AddFromURI(SURI string, meta map[string]interface{}, tp SupportedKeyType) {
+// AddFromURI derives the pair of the given type from the SURI and adds it to the keyring by its address
+func (kr *Keyring) AddFromURI(SURI string, meta map[string]interface{}, tp SupportedKeyType) (KeyringPair, error) {
+	pair, err := KeyringPairFromURI(SURI, tp)
+	if err != nil {
+		return nil, err
+	}
 
+	pair.SetMeta(meta)
+	if kr.Pairs == nil {
+		kr.Pairs = make(map[string]KeyringPair)
+	}
+	kr.Pairs[pair.Address()] = pair
+	return pair, nil
 }
 
 type KeyringPair interface {
 	Type() SupportedKeyType
+	// Address is the SS58 address of the public key
 	Address() string
-	DecodePkcs8(passphrase string, encoded []byte)
-	EncodePkcs8(passphrase string) []byte
 	Meta() map[string]interface{}
+	// IsLocked is set once the pair is locked, a locked pair can't sign
 	IsLocked() bool
+	// Lock removes the secret key of the pair
 	Lock()
 	PublicKey() []byte
 	SetMeta(meta map[string]interface{})
-	Sign(message []byte) []byte
-	// TODO
-	Json (passphrase string)
-	Verify (message []byte, signature []byte) bool
-}
-
-
-func sign(privKey []byte, msg []byte) {
-	ed25519.Sign(privKey, msg)
+	Sign(message []byte) ([]byte, error)
+	Verify(message []byte, signature []byte) bool
 }
 
 var reCapture = regexp.MustCompile("^(\\w+( \\w+)*)((//?[^/]+)*)(///(.*))?$")
@@ -0,0 +1,25 @@
+package signature
+
+import "context"
+
+// Signer signs the signature payloads of the extrinsics, eg: with a key held out of process
+type Signer interface {
+	Sign(ctx context.Context, payload []byte) ([]byte, error)
+}
+
+// keyringSigner signs in process with a keyring pair
+type keyringSigner struct {
+	pair KeyringPair
+}
+
+// NewKeyringSigner signs with the keyring pair
+func NewKeyringSigner(pair KeyringPair) Signer {
+	return keyringSigner{pair: pair}
+}
+
+func (s keyringSigner) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.pair.Sign(payload)
+}
@@ -1,6 +1,8 @@
 package substrate
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/minio/blake2b-simd"
@@ -8,11 +10,18 @@ import (
 	bbb "golang.org/x/crypto/blake2b"
 	"testing"
 	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
 )
 
 func TestState_GetMetaData(t *testing.T) {
-	s := State{nonetwork: true}
-	res, err := s.MetaData([]byte{})
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	s := NewStateRPC(c)
+	res, err := s.MetaData(context.Background(), []byte{})
 	assert.NoError(t, err)
 	assert.Equal(t, "system", res.Metadata.Modules[0].Name)
 	// fmt.Println(res)
@@ -25,3 +34,40 @@ func TestBlake(t *testing.T) {
  	fmt.Println(hexutil.Encode(b[:]))
 	fmt.Println(hexutil.Encode(b2[:]))
 }
+
+func TestState_StorageMulti(t *testing.T) {
+	m := newMockClient()
+	m.handle("state_getStorage", func(args ...interface{}) (interface{}, error) {
+		switch args[0].(string) {
+		case "0x01":
+			return "0x0102", nil
+		case "0x02":
+			return nil, nil
+		default:
+			return nil, errors.New("unknown key")
+		}
+	})
+
+	s := NewStateRPC(m)
+	values, err := s.StorageMulti(context.Background(), [][]byte{{1}, {2}}, Hash{1})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{{1, 2}, nil}, values)
+	assert.Equal(t, 1, m.batches)
+	assert.Equal(t, 2, m.callCount("state_getStorage"))
+
+	_, err = s.StorageMulti(context.Background(), [][]byte{{1}, {3}}, nil)
+	assert.Error(t, err)
+}
+
+func TestState_Storage_Cancelled(t *testing.T) {
+	m := newMockClient()
+	m.handle("state_getStorage", func(args ...interface{}) (interface{}, error) {
+		return "0x01", nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := NewStateRPC(m).Storage(ctx, []byte{1}, nil)
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, 0, m.callCount("state_getStorage"))
+}
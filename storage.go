@@ -0,0 +1,105 @@
+package substrate
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/OneOfOne/xxhash"
+	"golang.org/x/crypto/blake2b"
+)
+
+// storage hashers as defined by the StorageHasher enum in the metadata v4, the hashers added by v11 follow
+const (
+	Blake2_128 uint8 = iota
+	Blake2_256
+	Twox128
+	Twox256
+	Twox64Concat
+	Blake2_128Concat
+	Identity
+)
+
+// StorageKey creates the key of a storage item, the key of a map item must be given in its encoded form.
+// eg: StorageKey("system", "Events") or StorageKey("system", "AccountNonce", accountID). The module names are
+// capitalised from v11, eg: System, they are compared case insensitively.
+func (m *MetadataV4) StorageKey(module, fn string, key ...[]byte) ([]byte, error) {
+	for _, n := range m.Modules {
+		if !strings.EqualFold(n.Name, module) {
+			continue
+		}
+
+		for _, s := range n.Storage {
+			if s.Name != fn {
+				continue
+			}
+
+			// from v11 the key is the hash of the prefix and the hash of the name followed by the hash of the map key
+			if m.version >= 11 {
+				prefix := append(hash(Twox128, []byte(n.Prefix)), hash(Twox128, []byte(s.Name))...)
+				switch s.Type {
+				case 0:
+					return prefix, nil
+				case 1:
+					if len(key) != 1 {
+						return nil, fmt.Errorf("storage map %s.%s requires a key", module, fn)
+					}
+					return append(prefix, hash(s.Map.Hasher, key[0])...), nil
+				default:
+					return nil, fmt.Errorf("storage double map %s.%s is not supported", module, fn)
+				}
+			}
+
+			prefix := []byte(n.Prefix + " " + s.Name)
+			switch s.Type {
+			case 0:
+				return hash(Twox128, prefix), nil
+			case 1:
+				if len(key) != 1 {
+					return nil, fmt.Errorf("storage map %s.%s requires a key", module, fn)
+				}
+				return hash(s.Map.Hasher, append(prefix, key[0]...)), nil
+			default:
+				return nil, fmt.Errorf("storage double map %s.%s is not supported", module, fn)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("storage %s.%s not found", module, fn)
+}
+
+// hash the data using the given storage hasher
+func hash(hasher uint8, data []byte) []byte {
+	switch hasher {
+	case Blake2_128:
+		h, _ := blake2b.New(16, nil)
+		h.Write(data)
+		return h.Sum(nil)
+	case Blake2_256:
+		h := blake2b.Sum256(data)
+		return h[:]
+	case Twox128:
+		return twox(data, 2)
+	case Twox256:
+		return twox(data, 4)
+	case Twox64Concat:
+		return append(twox(data, 1), data...)
+	case Blake2_128Concat:
+		return append(hash(Blake2_128, data), data...)
+	case Identity:
+		return data
+	default:
+		panic(fmt.Sprintf("unknown storage hasher %d", hasher))
+	}
+}
+
+// twox concatenates the xxhash64 of the data with seeds 0..rounds
+func twox(data []byte, rounds int) []byte {
+	res := make([]byte, 0, rounds*8)
+	for i := 0; i < rounds; i++ {
+		h := make([]byte, 8)
+		binary.LittleEndian.PutUint64(h, xxhash.Checksum64S(data, uint64(i)))
+		res = append(res, h...)
+	}
+	return res
+}
@@ -0,0 +1,30 @@
+package substrate
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataV4_StorageKey(t *testing.T) {
+	meta := testMetadata(t)
+	key, err := meta.Metadata.StorageKey("system", "Events")
+	assert.NoError(t, err)
+	assert.Equal(t, "0xcc956bdb7605e3547539f321ac2bc95c", hexutil.Encode(key))
+
+	key, err = meta.Metadata.StorageKey("sudo", "Key")
+	assert.NoError(t, err)
+	assert.Equal(t, "0x50a63a871aced22e88ee6466fe5aa5d9", hexutil.Encode(key))
+
+	// map keys are hashed with the prefix
+	key, err = meta.Metadata.StorageKey("system", "AccountNonce", make([]byte, 32))
+	assert.NoError(t, err)
+	assert.Equal(t, hash(Blake2_256, append([]byte("System AccountNonce"), make([]byte, 32)...)), key)
+
+	_, err = meta.Metadata.StorageKey("system", "AccountNonce")
+	assert.Error(t, err)
+
+	_, err = meta.Metadata.StorageKey("system", "Unknown")
+	assert.Error(t, err)
+}
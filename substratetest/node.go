@@ -0,0 +1,390 @@
+// Package substratetest provides an in-process substrate JSON-RPC node for tests
+package substratetest
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/blake2b"
+)
+
+// Error codes of the transaction pool
+const (
+	ErrCodeInvalidTransaction = 1010
+	ErrCodeUnknownTransaction = 1011
+	ErrCodeTemporarilyBanned  = 1012
+	ErrCodeAlreadyImported    = 1013
+	ErrCodeTooLowPriority     = 1014
+	ErrCodeCycleDetected      = 1015
+	ErrCodeImmediatelyDropped = 1016
+)
+
+// PoolError is an error of the transaction pool as returned by author_submitExtrinsic, the reason of the rejection
+// is sent as the data of the error like substrate does, eg: "Transaction is outdated" for an Invalid Transaction
+type PoolError struct {
+	Code    int
+	Message string
+	Data    string
+}
+
+func (e *PoolError) Error() string {
+	if e.Data == "" {
+		return e.Message
+	}
+	return e.Message + ": " + e.Data
+}
+
+func (e *PoolError) ErrorCode() int {
+	return e.Code
+}
+
+// RuntimeVersion is the result of state_getRuntimeVersion
+type RuntimeVersion struct {
+	SpecName           string        `json:"specName"`
+	ImplName           string        `json:"implName"`
+	AuthoringVersion   uint32        `json:"authoringVersion"`
+	SpecVersion        uint32        `json:"specVersion"`
+	ImplVersion        uint32        `json:"implVersion"`
+	TransactionVersion uint32        `json:"transactionVersion"`
+	Apis               []interface{} `json:"apis"`
+}
+
+type block struct {
+	hash       []byte
+	header     header
+	extrinsics []string
+	metadata   string
+	runtime    RuntimeVersion
+	// storage is the state after the block
+	storage map[string]string
+	// included are the watched extrinsics of the block, notified once the block is finalized
+	included []*poolTx
+}
+
+type header struct {
+	ParentHash     string `json:"parentHash"`
+	Number         string `json:"number"`
+	StateRoot      string `json:"stateRoot"`
+	ExtrinsicsRoot string `json:"extrinsicsRoot"`
+	Digest         struct {
+		Logs []string `json:"logs"`
+	} `json:"digest"`
+}
+
+type signedBlock struct {
+	Block struct {
+		Header     header   `json:"header"`
+		Extrinsics []string `json:"extrinsics"`
+	} `json:"block"`
+	Justification *string `json:"justification"`
+}
+
+// Node serves the chain, state, author and system RPCs of a chain produced on demand with ProduceBlock.
+// Submitted extrinsics are checked against the nonces of their senders and kept in the pool until they are
+// included in a block. The blocks are finalized once produced unless a finality lag is set.
+type Node struct {
+	// URL is the websocket url of the node
+	URL string
+
+	http             *httptest.Server
+	rpcMethods       map[string]method
+	rpcSubscriptions map[string]subscription
+
+	connsMu sync.Mutex
+	// conns are the open connections, nil once the node is closed
+	conns            map[*conn]struct{}
+	nextSubscription uint64
+
+	mu       sync.Mutex
+	metadata string
+	runtime  RuntimeVersion
+	blocks   []*block
+	byHash   map[string]*block
+	// finalized is the number of the finalized block, finalityLag blocks behind the best block
+	finalized   uint64
+	finalityLag uint64
+	// state is the storage of the next block
+	state  map[string]string
+	nonces map[[32]byte]uint64
+	pool   []*poolTx
+	// extensions are the signed extensions of the submitted extrinsics, the nonce and the era follow the
+	// signature when empty
+	extensions []string
+
+	subs subscriptions
+}
+
+// NewNode starts a node with the genesis block, serving the example metadata
+func NewNode() *Node {
+	n := &Node{
+		conns:    make(map[*conn]struct{}),
+		metadata: MetadataV4,
+		runtime:  RuntimeVersion{SpecName: "node", ImplName: "substrate-node", AuthoringVersion: 1, SpecVersion: 1, ImplVersion: 1, TransactionVersion: 1, Apis: []interface{}{}},
+		byHash:   make(map[string]*block),
+		state:    make(map[string]string),
+		nonces:   make(map[[32]byte]uint64),
+		subs:     newSubscriptions(),
+	}
+	n.addBlock(nil)
+	n.rpcMethods = n.methods()
+	n.rpcSubscriptions = n.subscriptions()
+
+	n.http = httptest.NewServer(n.handler())
+	n.URL = "ws" + strings.TrimPrefix(n.http.URL, "http")
+	return n
+}
+
+// Close stops the node and closes the connections
+func (n *Node) Close() {
+	n.connsMu.Lock()
+	conns := n.conns
+	n.conns = nil
+	n.connsMu.Unlock()
+	for c := range conns {
+		c.ws.Close()
+	}
+	n.http.Close()
+}
+
+// SetMetadata sets the hex encoded metadata of the best block and the following blocks
+func (n *Node) SetMetadata(metadata string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.metadata = metadata
+	n.blocks[len(n.blocks)-1].metadata = metadata
+}
+
+// SetSignedExtensions sets the signed extensions of the submitted extrinsics in order, eg: "CheckMortality",
+// "CheckNonce", "ChargeTransactionPayment". Only the substrate extensions are supported.
+func (n *Node) SetSignedExtensions(names ...string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.extensions = names
+}
+
+// SetRuntimeVersion sets the runtime version of the best block and the following blocks
+func (n *Node) SetRuntimeVersion(v RuntimeVersion) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.runtime = v
+	n.blocks[len(n.blocks)-1].runtime = v
+}
+
+// SetFinalityLag sets the number of blocks the finalized block is behind the best block, 0 by default. The
+// extrinsics are finalized with their block.
+func (n *Node) SetFinalityLag(lag uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.finalityLag = lag
+}
+
+// FinalizedHash returns the hash of the finalized block
+func (n *Node) FinalizedHash() []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.blocks[n.finalized].hash
+}
+
+// SetStorage sets the value of the key in the state of the next block, nil removes the value
+func (n *Node) SetStorage(key, value []byte) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if value == nil {
+		delete(n.state, hexutil.Encode(key))
+		return
+	}
+	n.state[hexutil.Encode(key)] = hexutil.Encode(value)
+}
+
+// SetNonce sets the nonce of the account
+func (n *Node) SetNonce(account [32]byte, nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.nonces[account] = nonce
+}
+
+// Nonce returns the nonce of the account as of the best block
+func (n *Node) Nonce(account [32]byte) uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.nonces[account]
+}
+
+// BlockHash returns the hash of the block with the given number, nil if there is no such block
+func (n *Node) BlockHash(number uint64) []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if number >= uint64(len(n.blocks)) {
+		return nil
+	}
+	return n.blocks[number].hash
+}
+
+// Extrinsics returns the encoded extrinsics of the block
+func (n *Node) Extrinsics(blockHash []byte) [][]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	b, ok := n.byHash[hexutil.Encode(blockHash)]
+	if !ok {
+		return nil
+	}
+
+	var res [][]byte
+	for _, e := range b.extrinsics {
+		res = append(res, hexutil.MustDecode(e))
+	}
+	return res
+}
+
+// Pending returns the encoded extrinsics of the pool, ready and future
+func (n *Node) Pending() [][]byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	var res [][]byte
+	for _, tx := range n.pool {
+		res = append(res, tx.encoded)
+	}
+	return res
+}
+
+// Subscriptions returns the number of subscriptions of the connected clients
+func (n *Node) Subscriptions() int {
+	n.connsMu.Lock()
+	defer n.connsMu.Unlock()
+	var count int
+	for c := range n.conns {
+		c.mu.Lock()
+		count += len(c.subs)
+		c.mu.Unlock()
+	}
+	return count
+}
+
+// Drop removes the extrinsic with the given hash from the pool and notifies its watcher, returns false if the
+// extrinsic is not in the pool
+func (n *Node) Drop(hash []byte) bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, tx := range n.pool {
+		if bytes.Equal(tx.hash, hash) {
+			n.remove(tx)
+			n.subs.notifyWatch(tx, "dropped")
+			n.subs.closeWatch(tx)
+			return true
+		}
+	}
+	return false
+}
+
+// ProduceBlock includes the ready extrinsics of the pool in a new block, notifies the new head and the blocks
+// finalized with it and returns the hash of the block
+func (n *Node) ProduceBlock() []byte {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var included []*poolTx
+	pool := n.pool[:0]
+	for _, tx := range n.pool {
+		if tx.ready {
+			included = append(included, tx)
+			if tx.signed && tx.nonce >= n.nonces[tx.sender] {
+				n.nonces[tx.sender] = tx.nonce + 1
+			}
+			continue
+		}
+		pool = append(pool, tx)
+	}
+	n.pool = pool
+
+	// the extrinsics of a sender are included in the order of their nonces
+	sort.SliceStable(included, func(i, j int) bool {
+		return included[i].nonce < included[j].nonce
+	})
+	var extrinsics []string
+	for _, tx := range included {
+		extrinsics = append(extrinsics, hexutil.Encode(tx.encoded))
+	}
+
+	b := n.addBlock(extrinsics)
+	b.included = included
+	n.promote()
+
+	n.subs.notifyHeads(b.header)
+	number := uint64(len(n.blocks) - 1)
+	if number >= n.finalityLag {
+		n.finalize(number - n.finalityLag)
+	}
+	return b.hash
+}
+
+// finalize finalizes the blocks up to the number and notifies them in order, the sender must hold the lock
+func (n *Node) finalize(number uint64) {
+	for ; n.finalized < number; n.finalized++ {
+		b := n.blocks[n.finalized+1]
+		for _, tx := range b.included {
+			n.subs.notifyWatch(tx, map[string]string{"finalized": hexutil.Encode(b.hash)})
+			n.subs.closeWatch(tx)
+		}
+		b.included = nil
+		n.subs.notifyFinalized(b.header)
+	}
+}
+
+// addBlock adds a block on the best block with the current state
+func (n *Node) addBlock(extrinsics []string) *block {
+	parent := make([]byte, 32)
+	if len(n.blocks) > 0 {
+		parent = n.blocks[len(n.blocks)-1].hash
+	}
+
+	storage := make(map[string]string, len(n.state))
+	for k, v := range n.state {
+		storage[k] = v
+	}
+
+	number := uint64(len(n.blocks))
+	b := &block{extrinsics: extrinsics, metadata: n.metadata, runtime: n.runtime, storage: storage}
+	root := blake2b.Sum256([]byte(strings.Join(extrinsics, "")))
+	stateRoot := blake2b.Sum256([]byte(fmt.Sprintf("%d", number)))
+	b.header = header{
+		ParentHash:     hexutil.Encode(parent),
+		Number:         hexutil.EncodeUint64(number),
+		StateRoot:      hexutil.Encode(stateRoot[:]),
+		ExtrinsicsRoot: hexutil.Encode(root[:]),
+	}
+	b.header.Digest.Logs = []string{}
+
+	// the hash of the SCALE encoded header
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	enc.Write(parent)
+	enc.EncodeUintCompact(number)
+	enc.Write(stateRoot[:])
+	enc.Write(root[:])
+	enc.EncodeUintCompact(0)
+	h := blake2b.Sum256(bb.Bytes())
+	b.hash = h[:]
+
+	n.blocks = append(n.blocks, b)
+	n.byHash[hexutil.Encode(b.hash)] = b
+	return b
+}
+
+// blockAt returns the block with the given hash or the best block if the hash is not set
+func (n *Node) blockAt(hash *string) (*block, error) {
+	if hash == nil || *hash == "" {
+		return n.blocks[len(n.blocks)-1], nil
+	}
+
+	b, ok := n.byHash[strings.ToLower(*hash)]
+	if !ok {
+		return nil, fmt.Errorf("unknown block %s", *hash)
+	}
+	return b, nil
+}
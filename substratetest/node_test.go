@@ -0,0 +1,223 @@
+package substratetest
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	substrate "github.com/vimukthi-git/go-substrate"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/blake2b"
+)
+
+// encodeTx encodes a signed extrinsic of the sender with an empty signature and call
+func encodeTx(sender byte, nonce uint64, call ...byte) string {
+	var tx bytes.Buffer
+	enc := scalecodec.NewEncoder(&tx)
+	enc.PushByte(0x81)
+	enc.PushByte(0xff)
+	enc.Write(bytes.Repeat([]byte{sender}, 32))
+	enc.Write(make([]byte, 64))
+	enc.EncodeUintCompact(nonce)
+	enc.PushByte(0)
+	enc.Write(call)
+
+	var bb bytes.Buffer
+	enc = scalecodec.NewEncoder(&bb)
+	enc.EncodeUintCompact(uint64(tx.Len()))
+	enc.Write(tx.Bytes())
+	return hexutil.Encode(bb.Bytes())
+}
+
+func dial(t *testing.T, n *Node) substrate.Client {
+	c, err := substrate.Connect(n.URL)
+	assert.NoError(t, err)
+	return c
+}
+
+func TestNode_Pool(t *testing.T) {
+	n := NewNode()
+	defer n.Close()
+	c := dial(t, n)
+	defer c.Close()
+
+	var sender [32]byte
+	copy(sender[:], bytes.Repeat([]byte{1}, 32))
+	n.SetNonce(sender, 1)
+
+	var hash string
+	assert.NoError(t, c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 2)))
+	err := c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 2))
+	assert.Equal(t, ErrCodeAlreadyImported, err.(rpc.Error).ErrorCode())
+	err = c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 2, 1))
+	assert.Equal(t, ErrCodeTooLowPriority, err.(rpc.Error).ErrorCode())
+	err = c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 0))
+	assert.Equal(t, ErrCodeInvalidTransaction, err.(rpc.Error).ErrorCode())
+	// the reason is sent as the data of the error
+	assert.Equal(t, "Invalid Transaction", err.Error())
+	assert.Equal(t, "Transaction is outdated", err.(substrate.DataError).ErrorData())
+	err = c.Call(&hash, "author_submitExtrinsic", "0x0102")
+	assert.Equal(t, ErrCodeInvalidTransaction, err.(rpc.Error).ErrorCode())
+
+	// nonce 2 is future until nonce 1 is submitted
+	block := n.ProduceBlock()
+	assert.Empty(t, n.Extrinsics(block))
+	assert.NoError(t, c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 1)))
+	var pending []string
+	assert.NoError(t, c.Call(&pending, "author_pendingExtrinsics"))
+	assert.Len(t, pending, 2)
+
+	block = n.ProduceBlock()
+	assert.Len(t, n.Extrinsics(block), 2)
+	assert.Equal(t, hexutil.MustDecode(encodeTx(1, 1)), n.Extrinsics(block)[0])
+	assert.Equal(t, uint64(3), n.Nonce(sender))
+	assert.Empty(t, n.Pending())
+}
+
+func TestNode_SubmitAndWatchExtrinsic(t *testing.T) {
+	n := NewNode()
+	defer n.Close()
+	c := dial(t, n)
+	defer c.Close()
+
+	statuses := make(chan interface{}, 3)
+	sub, err := c.Subscribe(context.Background(), substrate.ExtrinsicSubscription, statuses, encodeTx(1, 1))
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	assert.Equal(t, "future", <-statuses)
+
+	var hash string
+	assert.NoError(t, c.Call(&hash, "author_submitExtrinsic", encodeTx(1, 0)))
+	assert.Equal(t, "ready", <-statuses)
+	block := n.ProduceBlock()
+	assert.Equal(t, map[string]interface{}{"finalized": hexutil.Encode(block)}, <-statuses)
+}
+
+// encodeTipTx encodes a signed extrinsic of the sender paying the tip for the CheckNonce and ChargeTransactionPayment
+// signed extensions
+func encodeTipTx(sender byte, nonce, tip uint64) string {
+	var tx bytes.Buffer
+	enc := scalecodec.NewEncoder(&tx)
+	enc.PushByte(0x81)
+	enc.PushByte(0xff)
+	enc.Write(bytes.Repeat([]byte{sender}, 32))
+	enc.Write(make([]byte, 64))
+	enc.EncodeUintCompact(nonce)
+	enc.EncodeUintCompact(tip)
+
+	var bb bytes.Buffer
+	enc = scalecodec.NewEncoder(&bb)
+	enc.EncodeUintCompact(uint64(tx.Len()))
+	enc.Write(tx.Bytes())
+	return hexutil.Encode(bb.Bytes())
+}
+
+func TestNode_ReplaceAndDrop(t *testing.T) {
+	n := NewNode()
+	defer n.Close()
+	n.SetSignedExtensions("CheckNonce", "ChargeTransactionPayment")
+	c := dial(t, n)
+	defer c.Close()
+
+	statuses := make(chan interface{}, 3)
+	sub, err := c.Subscribe(context.Background(), substrate.ExtrinsicSubscription, statuses, encodeTipTx(1, 0, 5))
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	assert.Equal(t, "ready", <-statuses)
+
+	// the extrinsic is only replaced with a higher tip
+	var hash string
+	err = c.Call(&hash, "author_submitExtrinsic", encodeTipTx(1, 0, 5))
+	assert.Equal(t, ErrCodeAlreadyImported, err.(rpc.Error).ErrorCode())
+	err = c.Call(&hash, "author_submitExtrinsic", encodeTipTx(1, 0, 4))
+	assert.Equal(t, ErrCodeTooLowPriority, err.(rpc.Error).ErrorCode())
+	replacement := make(chan interface{}, 3)
+	rsub, err := c.Subscribe(context.Background(), substrate.ExtrinsicSubscription, replacement, encodeTipTx(1, 0, 6))
+	assert.NoError(t, err)
+	defer rsub.Unsubscribe()
+	assert.Equal(t, "ready", <-replacement)
+	assert.Len(t, n.Pending(), 1)
+	h := blake2b.Sum256(hexutil.MustDecode(encodeTipTx(1, 0, 6)))
+	assert.Equal(t, map[string]interface{}{"usurped": hexutil.Encode(h[:])}, <-statuses)
+
+	assert.True(t, n.Drop(h[:]))
+	assert.Equal(t, "dropped", <-replacement)
+	assert.False(t, n.Drop(h[:]))
+	assert.Empty(t, n.Pending())
+}
+
+func TestNode_Chain(t *testing.T) {
+	n := NewNode()
+	defer n.Close()
+	c := dial(t, n)
+	defer c.Close()
+
+	heads := make(chan header, 1)
+	sub, err := c.Subscribe(context.Background(), substrate.FinalizedHeadSubscription, heads)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	n.SetStorage([]byte{1}, []byte{2})
+	n.SetMetadata("0x01")
+	block := n.ProduceBlock()
+	assert.Equal(t, "0x1", (<-heads).Number)
+
+	var hash string
+	assert.NoError(t, c.Call(&hash, "chain_getBlockHash", 1))
+	assert.Equal(t, hexutil.Encode(block), hash)
+	var h header
+	assert.NoError(t, c.Call(&h, "chain_getHeader"))
+	assert.Equal(t, hexutil.Encode(n.BlockHash(0)), h.ParentHash)
+
+	var value, metadata string
+	assert.NoError(t, c.Call(&value, "state_getStorage", "0x01", hash))
+	assert.Equal(t, "0x02", value)
+	assert.NoError(t, c.Call(&metadata, "state_getMetadata", hexutil.Encode(n.BlockHash(0))))
+	assert.Equal(t, "0x01", metadata)
+	assert.Error(t, c.Call(&metadata, "state_getMetadata", "0x02"))
+
+	// the geth subscription naming is not served
+	err = c.Call(&hash, "chain_subscribe", "newHead")
+	assert.Equal(t, errCodeMethodNotFound, err.(rpc.Error).ErrorCode())
+	newHeads := make(chan header, 1)
+	nsub, err := c.Subscribe(context.Background(), substrate.NewHeadSubscription, newHeads)
+	assert.NoError(t, err)
+	nsub.Unsubscribe()
+}
+
+func TestNode_Finality(t *testing.T) {
+	n := NewNode()
+	defer n.Close()
+	n.SetFinalityLag(2)
+	c := dial(t, n)
+	defer c.Close()
+
+	heads := make(chan header, 3)
+	sub, err := c.Subscribe(context.Background(), substrate.FinalizedHeadSubscription, heads)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+	statuses := make(chan interface{}, 2)
+	wsub, err := c.Subscribe(context.Background(), substrate.ExtrinsicSubscription, statuses, encodeTx(1, 0))
+	assert.NoError(t, err)
+	defer wsub.Unsubscribe()
+	assert.Equal(t, "ready", <-statuses)
+
+	// the extrinsic is finalized with its block, two blocks later
+	block := n.ProduceBlock()
+	n.ProduceBlock()
+	var hash string
+	assert.NoError(t, c.Call(&hash, "chain_getFinalizedHead"))
+	assert.Equal(t, hexutil.Encode(n.BlockHash(0)), hash)
+	assert.Empty(t, statuses)
+
+	n.ProduceBlock()
+	assert.Equal(t, "0x1", (<-heads).Number)
+	assert.Equal(t, map[string]interface{}{"finalized": hexutil.Encode(block)}, <-statuses)
+	assert.NoError(t, c.Call(&hash, "chain_getFinalizedHead"))
+	assert.Equal(t, hexutil.Encode(block), hash)
+	assert.Equal(t, block, n.FinalizedHash())
+	assert.Empty(t, heads)
+}
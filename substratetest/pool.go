@@ -0,0 +1,169 @@
+package substratetest
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"golang.org/x/crypto/blake2b"
+)
+
+// poolTx is an extrinsic of the pool
+type poolTx struct {
+	encoded []byte
+	hash    []byte
+	signed  bool
+	sender  [32]byte
+	nonce   uint64
+	tip     uint64
+	// ready is set once the nonce follows the nonce of the sender, future otherwise
+	ready bool
+}
+
+// decodeTx reads the sender, the nonce and the tip of the extrinsic with the signed extensions, the signature is not verified
+func decodeTx(encoded []byte, extensions []string) (tx *poolTx, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("invalid extrinsic: %v", rec)
+		}
+	}()
+
+	h := blake2b.Sum256(encoded)
+	tx = &poolTx{encoded: encoded, hash: h[:]}
+	r := bytes.NewReader(encoded)
+	dec := scalecodec.NewDecoder(r)
+	l := dec.DecodeUintCompact()
+	if l != uint64(r.Len()) {
+		return nil, fmt.Errorf("invalid extrinsic length %d, %d bytes remaining", l, r.Len())
+	}
+
+	version := dec.ReadOneByte()
+	if version&0x80 == 0 {
+		return tx, nil
+	}
+
+	tx.signed = true
+	if a := dec.ReadOneByte(); a != 0xff {
+		return nil, fmt.Errorf("unsupported address type %d", a)
+	}
+	dec.Read(tx.sender[:])
+	// the MultiSignature type from version 4
+	if version&0x7f >= 4 {
+		dec.ReadOneByte()
+	}
+	sig := make([]byte, 64)
+	dec.Read(sig)
+	if len(extensions) == 0 {
+		tx.nonce = dec.DecodeUintCompact()
+		return tx, nil
+	}
+
+	for _, ext := range extensions {
+		switch ext {
+		case "CheckEra", "CheckMortality":
+			if dec.ReadOneByte() != 0 {
+				dec.ReadOneByte()
+			}
+		case "CheckNonce":
+			tx.nonce = dec.DecodeUintCompact()
+		case "ChargeTransactionPayment":
+			tx.tip = dec.DecodeUintCompact()
+		case "CheckSpecVersion", "CheckTxVersion", "CheckGenesis", "CheckWeight", "CheckNonZeroSender":
+		default:
+			return nil, fmt.Errorf("unsupported signed extension %s", ext)
+		}
+	}
+	return tx, nil
+}
+
+// submit adds the extrinsic to the pool, the sender must hold the lock
+func (n *Node) submit(encoded []byte) (*poolTx, error) {
+	tx, err := decodeTx(encoded, n.extensions)
+	if err != nil {
+		return nil, &PoolError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction", Data: err.Error()}
+	}
+
+	var replaced *poolTx
+	for _, p := range n.pool {
+		if bytes.Equal(p.hash, tx.hash) {
+			return nil, &PoolError{Code: ErrCodeAlreadyImported, Message: "Transaction Already Imported",
+				Data: "The transaction is already in the pool."}
+		}
+		// an extrinsic with the same nonce replaces the one in the pool with a higher tip
+		if tx.signed && p.signed && p.sender == tx.sender && p.nonce == tx.nonce {
+			if tx.tip <= p.tip {
+				return nil, &PoolError{Code: ErrCodeTooLowPriority, Message: fmt.Sprintf("Priority is too low: (%d vs %d)", p.tip, tx.tip),
+					Data: "The transaction has too low priority to replace another transaction already in the pool."}
+			}
+			replaced = p
+		}
+	}
+
+	if tx.signed && tx.nonce < n.nonces[tx.sender] {
+		return nil, &PoolError{Code: ErrCodeInvalidTransaction, Message: "Invalid Transaction", Data: "Transaction is outdated"}
+	}
+
+	if replaced != nil {
+		n.remove(replaced)
+		n.subs.notifyWatch(replaced, map[string]string{"usurped": tx.hashHex()})
+		n.subs.closeWatch(replaced)
+	}
+
+	tx.ready = !tx.signed
+	n.pool = append(n.pool, tx)
+	n.promote()
+	return tx, nil
+}
+
+// remove removes the extrinsic from the pool, the sender must hold the lock
+func (n *Node) remove(tx *poolTx) {
+	for i, p := range n.pool {
+		if p == tx {
+			n.pool = append(n.pool[:i], n.pool[i+1:]...)
+			return
+		}
+	}
+}
+
+// promote makes the future extrinsics whose nonce follows the ready ones of their sender ready
+func (n *Node) promote() {
+	bySender := make(map[[32]byte][]*poolTx)
+	for _, tx := range n.pool {
+		if tx.signed {
+			bySender[tx.sender] = append(bySender[tx.sender], tx)
+		}
+	}
+
+	for sender, txs := range bySender {
+		sort.Slice(txs, func(i, j int) bool {
+			return txs[i].nonce < txs[j].nonce
+		})
+
+		next := n.nonces[sender]
+		for _, tx := range txs {
+			if tx.nonce != next {
+				break
+			}
+
+			if !tx.ready {
+				tx.ready = true
+				n.subs.notifyWatch(tx, "ready")
+			}
+			next++
+		}
+	}
+}
+
+// status of the extrinsic in the pool for author_submitAndWatchExtrinsic
+func (tx *poolTx) status() string {
+	if tx.ready {
+		return "ready"
+	}
+	return "future"
+}
+
+func (tx *poolTx) hashHex() string {
+	return hexutil.Encode(tx.hash)
+}
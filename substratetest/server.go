@@ -0,0 +1,494 @@
+package substratetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"golang.org/x/net/websocket"
+)
+
+// Error codes of JSON-RPC 2.0
+const (
+	errCodeParse          = -32700
+	errCodeMethodNotFound = -32601
+	errCodeInvalidParams  = -32602
+	// errCodeServer is the code of the errors of the node other than the pool errors
+	errCodeServer = -32000
+)
+
+// writeTimeout bounds the write of a response or a notification to a connection
+const writeTimeout = 10 * time.Second
+
+// request is a JSON-RPC 2.0 request
+type request struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+// response is a JSON-RPC 2.0 response, the result is set unless the request failed
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error of a response, substrate sends the details of an error in its data
+type rpcError struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// notification is a JSON-RPC 2.0 notification of a subscription
+type notification struct {
+	Version string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  struct {
+		Subscription uint64      `json:"subscription"`
+		Result       interface{} `json:"result"`
+	} `json:"params"`
+}
+
+// paramsError is returned when the params of a request can't be decoded
+type paramsError struct {
+	msg string
+}
+
+func (e *paramsError) Error() string {
+	return e.msg
+}
+
+// method serves a request with its params
+type method func(params json.RawMessage) (interface{}, error)
+
+// subscription serves a subscribe request, the subscribe func registers the subscriber and sets its remove func.
+// The subscriber is notified with notification and unsubscribed with unsubscribe.
+type subscription struct {
+	notification string
+	unsubscribe  string
+	subscribe    func(sb *subscriber, params json.RawMessage) error
+}
+
+// decodeParams decodes the positional params of a request into the args, the missing trailing params are left unset
+func decodeParams(params json.RawMessage, args ...interface{}) error {
+	var raw []json.RawMessage
+	if len(params) > 0 && string(params) != "null" {
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return &paramsError{msg: "invalid params: " + err.Error()}
+		}
+	}
+
+	if len(raw) > len(args) {
+		return &paramsError{msg: "too many params"}
+	}
+
+	for i, r := range raw {
+		if err := json.Unmarshal(r, args[i]); err != nil {
+			return &paramsError{msg: "invalid params: " + err.Error()}
+		}
+	}
+	return nil
+}
+
+// toError converts the error of a request to the error of its response
+func toError(err error) *rpcError {
+	switch e := err.(type) {
+	case *PoolError:
+		re := &rpcError{Code: e.Code, Message: e.Message}
+		if e.Data != "" {
+			re.Data = e.Data
+		}
+		return re
+	case *paramsError:
+		return &rpcError{Code: errCodeInvalidParams, Message: e.msg}
+	default:
+		return &rpcError{Code: errCodeServer, Message: err.Error()}
+	}
+}
+
+// methods returns the methods served by the node by name
+func (n *Node) methods() map[string]method {
+	chain := &chainService{n}
+	state := &stateService{n}
+	author := &authorService{n}
+	system := &systemService{n}
+	return map[string]method{
+		"chain_getBlockHash": func(params json.RawMessage) (interface{}, error) {
+			var number *uint64
+			if err := decodeParams(params, &number); err != nil {
+				return nil, err
+			}
+			return chain.GetBlockHash(number), nil
+		},
+		"chain_getFinalizedHead": func(params json.RawMessage) (interface{}, error) {
+			return chain.GetFinalizedHead(), nil
+		},
+		"chain_getHeader": func(params json.RawMessage) (interface{}, error) {
+			var hash *string
+			if err := decodeParams(params, &hash); err != nil {
+				return nil, err
+			}
+			return chain.GetHeader(hash)
+		},
+		"chain_getBlock": func(params json.RawMessage) (interface{}, error) {
+			var hash *string
+			if err := decodeParams(params, &hash); err != nil {
+				return nil, err
+			}
+			return chain.GetBlock(hash)
+		},
+		"state_getMetadata": func(params json.RawMessage) (interface{}, error) {
+			var hash *string
+			if err := decodeParams(params, &hash); err != nil {
+				return nil, err
+			}
+			return state.GetMetadata(hash)
+		},
+		"state_getRuntimeVersion": func(params json.RawMessage) (interface{}, error) {
+			var hash *string
+			if err := decodeParams(params, &hash); err != nil {
+				return nil, err
+			}
+			return state.GetRuntimeVersion(hash)
+		},
+		"state_getStorage": func(params json.RawMessage) (interface{}, error) {
+			var key string
+			var hash *string
+			if err := decodeParams(params, &key, &hash); err != nil {
+				return nil, err
+			}
+			return state.GetStorage(key, hash)
+		},
+		"author_submitExtrinsic": func(params json.RawMessage) (interface{}, error) {
+			var extrinsic hexutil.Bytes
+			if err := decodeParams(params, &extrinsic); err != nil {
+				return nil, err
+			}
+			return author.SubmitExtrinsic(extrinsic)
+		},
+		"author_pendingExtrinsics": func(params json.RawMessage) (interface{}, error) {
+			return author.PendingExtrinsics(), nil
+		},
+		"system_name": func(params json.RawMessage) (interface{}, error) {
+			return system.Name(), nil
+		},
+		"system_version": func(params json.RawMessage) (interface{}, error) {
+			return system.Version(), nil
+		},
+		"system_chain": func(params json.RawMessage) (interface{}, error) {
+			return system.Chain(), nil
+		},
+		"system_health": func(params json.RawMessage) (interface{}, error) {
+			return system.Health(), nil
+		},
+		"system_accountNextIndex": func(params json.RawMessage) (interface{}, error) {
+			var address string
+			if err := decodeParams(params, &address); err != nil {
+				return nil, err
+			}
+			return system.AccountNextIndex(address)
+		},
+	}
+}
+
+// subscriptions returns the subscriptions served by the node by subscribe method
+func (n *Node) subscriptions() map[string]subscription {
+	chain := &chainService{n}
+	author := &authorService{n}
+	return map[string]subscription{
+		"chain_subscribeNewHead": {
+			notification: "chain_newHead",
+			unsubscribe:  "chain_unsubscribeNewHead",
+			subscribe: func(sb *subscriber, params json.RawMessage) error {
+				if err := decodeParams(params); err != nil {
+					return err
+				}
+				chain.SubscribeNewHead(sb)
+				return nil
+			},
+		},
+		"chain_subscribeFinalizedHeads": {
+			notification: "chain_finalizedHead",
+			unsubscribe:  "chain_unsubscribeFinalizedHeads",
+			subscribe: func(sb *subscriber, params json.RawMessage) error {
+				if err := decodeParams(params); err != nil {
+					return err
+				}
+				chain.SubscribeFinalizedHeads(sb)
+				return nil
+			},
+		},
+		"author_submitAndWatchExtrinsic": {
+			notification: "author_extrinsicUpdate",
+			unsubscribe:  "author_unwatchExtrinsic",
+			subscribe: func(sb *subscriber, params json.RawMessage) error {
+				var extrinsic hexutil.Bytes
+				if err := decodeParams(params, &extrinsic); err != nil {
+					return err
+				}
+				return author.SubmitAndWatchExtrinsic(sb, extrinsic)
+			},
+		},
+	}
+}
+
+// conn is a websocket connection of a client and its subscriptions
+type conn struct {
+	n  *Node
+	ws *websocket.Conn
+	// writeMu serialises the writes of the responses and the notifications
+	writeMu sync.Mutex
+
+	mu   sync.Mutex
+	subs map[uint64]*subscriber
+}
+
+// handler returns the websocket handler of the node, accepting any origin
+func (n *Node) handler() http.Handler {
+	return websocket.Server{
+		Handshake: func(*websocket.Config, *http.Request) error {
+			return nil
+		},
+		Handler: n.serve,
+	}
+}
+
+// serve reads the requests of the connection until it is closed, the requests are served in order
+func (n *Node) serve(ws *websocket.Conn) {
+	c := &conn{n: n, ws: ws, subs: make(map[uint64]*subscriber)}
+	if !n.track(c) {
+		ws.Close()
+		return
+	}
+	defer func() {
+		n.untrack(c)
+		ws.Close()
+		c.unsubscribeAll()
+	}()
+
+	for {
+		var data []byte
+		if err := websocket.Message.Receive(ws, &data); err != nil {
+			return
+		}
+		c.handle(data)
+	}
+}
+
+// track adds the connection to the connections closed with the node, returns false if the node is closed
+func (n *Node) track(c *conn) bool {
+	n.connsMu.Lock()
+	defer n.connsMu.Unlock()
+	if n.conns == nil {
+		return false
+	}
+	n.conns[c] = struct{}{}
+	return true
+}
+
+func (n *Node) untrack(c *conn) {
+	n.connsMu.Lock()
+	defer n.connsMu.Unlock()
+	delete(n.conns, c)
+}
+
+// handle serves a request or a batch of requests, the subscriptions are notified once their responses are written
+func (c *conn) handle(data []byte) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var batch []request
+		if err := json.Unmarshal(data, &batch); err != nil {
+			c.write(&response{Version: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: errCodeParse, Message: "Parse error"}})
+			return
+		}
+
+		resps := make([]*response, len(batch))
+		var started []*subscriber
+		for i := range batch {
+			var sb *subscriber
+			resps[i], sb = c.call(&batch[i])
+			if sb != nil {
+				started = append(started, sb)
+			}
+		}
+		c.write(resps)
+		for _, sb := range started {
+			sb.start()
+		}
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		c.write(&response{Version: "2.0", ID: json.RawMessage("null"), Error: &rpcError{Code: errCodeParse, Message: "Parse error"}})
+		return
+	}
+
+	resp, sb := c.call(&req)
+	c.write(resp)
+	if sb != nil {
+		sb.start()
+	}
+}
+
+// call serves the request, the subscriber of a subscribe request is returned to be started once the response is
+// written
+func (c *conn) call(req *request) (*response, *subscriber) {
+	resp := &response{Version: "2.0", ID: req.ID}
+	if len(resp.ID) == 0 {
+		resp.ID = json.RawMessage("null")
+	}
+
+	var res interface{}
+	var err error
+	var sb *subscriber
+	if m, ok := c.n.rpcMethods[req.Method]; ok {
+		res, err = m(req.Params)
+	} else if s, ok := c.n.rpcSubscriptions[req.Method]; ok {
+		sb, err = c.subscribe(s, req.Params)
+		if err == nil {
+			res = sb.id
+		}
+	} else if c.n.isUnsubscribe(req.Method) {
+		res, err = c.unsubscribe(req.Method, req.Params)
+	} else {
+		resp.Error = &rpcError{Code: errCodeMethodNotFound, Message: "Method not found"}
+		return resp, nil
+	}
+
+	if err != nil {
+		resp.Error = toError(err)
+		return resp, nil
+	}
+
+	b, err := json.Marshal(res)
+	if err != nil {
+		resp.Error = toError(err)
+		return resp, nil
+	}
+	resp.Result = b
+	return resp, sb
+}
+
+// isUnsubscribe returns true if the method unsubscribes from a subscription of the node
+func (n *Node) isUnsubscribe(method string) bool {
+	for _, s := range n.rpcSubscriptions {
+		if s.unsubscribe == method {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribe registers a subscriber of the connection with the subscription
+func (c *conn) subscribe(s subscription, params json.RawMessage) (*subscriber, error) {
+	c.n.connsMu.Lock()
+	c.n.nextSubscription++
+	id := c.n.nextSubscription
+	c.n.connsMu.Unlock()
+
+	sb := &subscriber{c: c, id: id, notification: s.notification, unsubscribe: s.unsubscribe}
+	if err := s.subscribe(sb, params); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.subs[id] = sb
+	c.mu.Unlock()
+	return sb, nil
+}
+
+// unsubscribe removes the subscription whose ID is the param, the subscription must be of the unsubscribe method
+func (c *conn) unsubscribe(method string, params json.RawMessage) (bool, error) {
+	var id uint64
+	if err := decodeParams(params, &id); err != nil {
+		return false, err
+	}
+
+	c.mu.Lock()
+	sb, ok := c.subs[id]
+	if ok && sb.unsubscribe == method {
+		delete(c.subs, id)
+	}
+	c.mu.Unlock()
+	if !ok || sb.unsubscribe != method {
+		return false, &paramsError{msg: "Invalid subscription id."}
+	}
+
+	sb.remove()
+	return true, nil
+}
+
+// unsubscribeAll removes the subscriptions of the closed connection
+func (c *conn) unsubscribeAll() {
+	c.mu.Lock()
+	subs := c.subs
+	c.subs = make(map[uint64]*subscriber)
+	c.mu.Unlock()
+	for _, sb := range subs {
+		sb.remove()
+	}
+}
+
+// write sends the message, the errors are ignored as the read of a broken connection fails
+func (c *conn) write(v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	websocket.Message.Send(c.ws, string(b))
+}
+
+// subscriber is a subscription of a connection. The notifications are queued until the response to the subscribe
+// request is written so that the client knows the subscription ID of the first notification.
+type subscriber struct {
+	c            *conn
+	id           uint64
+	notification string
+	unsubscribe  string
+	// remove is set by the subscription, removing the subscriber from the node
+	remove func()
+
+	mu      sync.Mutex
+	started bool
+	queue   []interface{}
+}
+
+// notify sends the result to the subscriber
+func (sb *subscriber) notify(result interface{}) {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	if !sb.started {
+		sb.queue = append(sb.queue, result)
+		return
+	}
+	sb.send(result)
+}
+
+// start sends the queued notifications, the following ones are sent when notified
+func (sb *subscriber) start() {
+	sb.mu.Lock()
+	defer sb.mu.Unlock()
+	for _, result := range sb.queue {
+		sb.send(result)
+	}
+	sb.queue = nil
+	sb.started = true
+}
+
+func (sb *subscriber) send(result interface{}) {
+	msg := &notification{Version: "2.0", Method: sb.notification}
+	msg.Params.Subscription = sb.id
+	msg.Params.Result = result
+	sb.c.write(msg)
+}
@@ -0,0 +1,204 @@
+package substratetest
+
+import (
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/vimukthi-git/go-substrate/signature"
+)
+
+// chainService serves chain_*
+type chainService struct {
+	n *Node
+}
+
+func (s *chainService) GetBlockHash(number *uint64) *string {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	b := s.n.blocks[len(s.n.blocks)-1]
+	if number != nil {
+		if *number >= uint64(len(s.n.blocks)) {
+			return nil
+		}
+		b = s.n.blocks[*number]
+	}
+
+	h := hexutil.Encode(b.hash)
+	return &h
+}
+
+func (s *chainService) GetFinalizedHead() string {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	return hexutil.Encode(s.n.blocks[s.n.finalized].hash)
+}
+
+func (s *chainService) GetHeader(hash *string) (*header, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	b, err := s.n.blockAt(hash)
+	if err != nil {
+		return nil, err
+	}
+	return &b.header, nil
+}
+
+func (s *chainService) GetBlock(hash *string) (*signedBlock, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	b, err := s.n.blockAt(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := &signedBlock{}
+	sb.Block.Header = b.header
+	sb.Block.Extrinsics = append([]string{}, b.extrinsics...)
+	return sb, nil
+}
+
+func (s *chainService) SubscribeNewHead(sb *subscriber) {
+	s.n.subs.subscribeHeads(sb, false)
+}
+
+func (s *chainService) SubscribeFinalizedHeads(sb *subscriber) {
+	s.n.subs.subscribeHeads(sb, true)
+}
+
+// stateService serves state_*
+type stateService struct {
+	n *Node
+}
+
+func (s *stateService) GetMetadata(hash *string) (string, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	b, err := s.n.blockAt(hash)
+	if err != nil {
+		return "", err
+	}
+	return b.metadata, nil
+}
+
+func (s *stateService) GetRuntimeVersion(hash *string) (*RuntimeVersion, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	b, err := s.n.blockAt(hash)
+	if err != nil {
+		return nil, err
+	}
+	v := b.runtime
+	return &v, nil
+}
+
+func (s *stateService) GetStorage(key string, hash *string) (*string, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	storage := s.n.state
+	if hash != nil && *hash != "" {
+		b, err := s.n.blockAt(hash)
+		if err != nil {
+			return nil, err
+		}
+		storage = b.storage
+	}
+
+	v, ok := storage[key]
+	if !ok {
+		return nil, nil
+	}
+	return &v, nil
+}
+
+// authorService serves author_*
+type authorService struct {
+	n *Node
+}
+
+func (s *authorService) SubmitExtrinsic(extrinsic hexutil.Bytes) (string, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	tx, err := s.n.submit(extrinsic)
+	if err != nil {
+		return "", err
+	}
+	return tx.hashHex(), nil
+}
+
+func (s *authorService) PendingExtrinsics() []string {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	res := make([]string, 0, len(s.n.pool))
+	for _, tx := range s.n.pool {
+		res = append(res, hexutil.Encode(tx.encoded))
+	}
+	return res
+}
+
+func (s *authorService) SubmitAndWatchExtrinsic(sb *subscriber, extrinsic hexutil.Bytes) error {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	tx, err := s.n.submit(extrinsic)
+	if err != nil {
+		return err
+	}
+	s.n.subs.watch(sb, tx)
+	return nil
+}
+
+// systemService serves system_*
+type systemService struct {
+	n *Node
+}
+
+func (s *systemService) Name() string {
+	return "substratetest"
+}
+
+func (s *systemService) Version() string {
+	return "1.0.0"
+}
+
+func (s *systemService) Chain() string {
+	return "Development"
+}
+
+type health struct {
+	Peers           uint64 `json:"peers"`
+	IsSyncing       bool   `json:"isSyncing"`
+	ShouldHavePeers bool   `json:"shouldHavePeers"`
+}
+
+func (s *systemService) Health() health {
+	return health{}
+}
+
+// AccountNextIndex returns the nonce following the nonces of the ready extrinsics of the account in the pool
+func (s *systemService) AccountNextIndex(address string) (uint64, error) {
+	s.n.mu.Lock()
+	defer s.n.mu.Unlock()
+	accounts := make(map[[32]byte]struct{})
+	for a := range s.n.nonces {
+		accounts[a] = struct{}{}
+	}
+	for _, tx := range s.n.pool {
+		accounts[tx.sender] = struct{}{}
+	}
+
+	for a := range accounts {
+		addr, err := signature.SS58Address(a[:])
+		if err != nil {
+			return 0, err
+		}
+		if addr != address {
+			continue
+		}
+
+		next := s.n.nonces[a]
+		for _, tx := range s.n.pool {
+			if tx.signed && tx.ready && tx.sender == a && tx.nonce >= next {
+				next = tx.nonce + 1
+			}
+		}
+		return next, nil
+	}
+	return 0, nil
+}
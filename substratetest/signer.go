@@ -0,0 +1,51 @@
+package substratetest
+
+import (
+	"context"
+	"sync"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// Signer is a signature.Signer for tests recording the signed payloads. The signature is the blake2b-512 hash of
+// the payload, so that the extrinsics are deterministic.
+type Signer struct {
+	mu       sync.Mutex
+	payloads [][]byte
+	err      error
+}
+
+// NewSigner returns a signer for tests
+func NewSigner() *Signer {
+	return &Signer{}
+}
+
+// Sign returns the hash of the payload or the error set with SetError
+func (s *Signer) Sign(ctx context.Context, payload []byte) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.payloads = append(s.payloads, append([]byte{}, payload...))
+	sig := blake2b.Sum512(payload)
+	return sig[:], nil
+}
+
+// SetError fails the following signatures with the error, nil to sign again
+func (s *Signer) SetError(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// Payloads returns the signed payloads
+func (s *Signer) Payloads() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]byte{}, s.payloads...)
+}
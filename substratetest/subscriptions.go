@@ -0,0 +1,84 @@
+package substratetest
+
+import (
+	"sync"
+)
+
+// subscriptions of the connected clients, notified with the lock of the node held
+type subscriptions struct {
+	mu        sync.Mutex
+	heads     map[*subscriber]struct{}
+	finalized map[*subscriber]struct{}
+	watches   map[*poolTx]*subscriber
+}
+
+func newSubscriptions() subscriptions {
+	return subscriptions{
+		heads:     make(map[*subscriber]struct{}),
+		finalized: make(map[*subscriber]struct{}),
+		watches:   make(map[*poolTx]*subscriber),
+	}
+}
+
+// subscribeHeads sends the new heads, or the finalized heads, to the subscriber until it is removed
+func (s *subscriptions) subscribeHeads(sb *subscriber, finalized bool) {
+	set := s.heads
+	if finalized {
+		set = s.finalized
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	set[sb] = struct{}{}
+	sb.remove = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(set, sb)
+	}
+}
+
+func (s *subscriptions) notifyHeads(h header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sb := range s.heads {
+		sb.notify(h)
+	}
+}
+
+func (s *subscriptions) notifyFinalized(h header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sb := range s.finalized {
+		sb.notify(h)
+	}
+}
+
+// watch sends the status changes of the extrinsic to the subscriber, starting with its current status
+func (s *subscriptions) watch(sb *subscriber, tx *poolTx) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.watches[tx] = sb
+	sb.notify(tx.status())
+	sb.remove = func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.watches[tx] == sb {
+			delete(s.watches, tx)
+		}
+	}
+}
+
+func (s *subscriptions) notifyWatch(tx *poolTx, status interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if sb, ok := s.watches[tx]; ok {
+		sb.notify(status)
+	}
+}
+
+// closeWatch stops notifying the extrinsic after a final status
+func (s *subscriptions) closeWatch(tx *poolTx) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.watches, tx)
+}
@@ -1,5 +1,7 @@
 package substrate
 
+import "context"
+
 type System struct {
 	client Client
 }
@@ -7,3 +9,42 @@ type System struct {
 func NewSystemRPC(client Client) *System {
 	return &System{client:client}
 }
+
+// Health of the node, the result of system_health
+type Health struct {
+	Peers           uint64 `json:"peers"`
+	IsSyncing       bool   `json:"isSyncing"`
+	ShouldHavePeers bool   `json:"shouldHavePeers"`
+}
+
+// Name system_name
+func (s *System) Name(ctx context.Context) (string, error) {
+	var res string
+	err := s.client.CallContext(ctx, &res, "system_name")
+	return res, err
+}
+
+// Version system_version
+func (s *System) Version(ctx context.Context) (string, error) {
+	var res string
+	err := s.client.CallContext(ctx, &res, "system_version")
+	return res, err
+}
+
+// Chain system_chain
+func (s *System) Chain(ctx context.Context) (string, error) {
+	var res string
+	err := s.client.CallContext(ctx, &res, "system_chain")
+	return res, err
+}
+
+// Health system_health
+func (s *System) Health(ctx context.Context) (*Health, error) {
+	var res Health
+	err := s.client.CallContext(ctx, &res, "system_health")
+	if err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
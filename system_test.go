@@ -0,0 +1,27 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSystem_Health(t *testing.T) {
+	m := newMockClient()
+	m.handle("system_health", func(args ...interface{}) (interface{}, error) {
+		return map[string]interface{}{"peers": 3, "isSyncing": true, "shouldHavePeers": true}, nil
+	})
+	m.handle("system_chain", func(args ...interface{}) (interface{}, error) {
+		return "Development", nil
+	})
+
+	s := NewSystemRPC(m)
+	h, err := s.Health(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, &Health{Peers: 3, IsSyncing: true, ShouldHavePeers: true}, h)
+
+	c, err := s.Chain(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "Development", c)
+}
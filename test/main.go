@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"sync/atomic"
@@ -11,25 +12,16 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/vimukthi-git/go-substrate"
 	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/signature"
 )
 
 const (
 	AnchorCommit = "kerplunk.commit"
-	SubKeySign = "sign-blob"
 
 	// Adjust below params accorging to your env + chain state + requirement
 
 	RPCEndPoint = "ws://127.0.0.1:9944"
 
-	// TODO query these from the chain
-	GenesisBlock  = "0x703bffa9bc816a5cd06ab8a95c2ff74e7a60cdaf269ffd64d325eb193266a656"
-	// BestBlock is the earliest block thats not already pruned
-	BestBlock  = "0xcd515661ff266920416ba9f1d48d8c532c586ab0101cbc987fbd74b4503abe87"
-	// StartNonce is the current account nonce for Alice (can't use other accounts for now)
-	StartNonce = 1520
-	// SubKeyCmd subkey command to create signatures
-	SubKeyCmd = "/Users/vimukthi/.cargo/bin/subkey"
-
 	NumAnchorsPerThread = 100
 	Concurrency = 4
 )
@@ -83,22 +75,19 @@ func main() {
 		panic(err)
 	}
 
-	hs, err := hexutil.Decode(BestBlock)
-	if err != nil {
-		panic(err)
-	}
-
-	s := substrate.NewStateRPC(client)
-	n, err := s.MetaData(hs)
+	alice, err := signature.KeyringPairFromURI(substrate.Alice, signature.SR25519)
 	if err != nil {
 		panic(err)
 	}
 
-	gs, err := hexutil.Decode(GenesisBlock)
+	// the chain and the account nonce are discovered from the node
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	authRPC, err := substrate.NewAuthor(ctx, client, substrate.NewKeyringAccount(alice))
 	if err != nil {
 		panic(err)
 	}
-	authRPC := substrate.NewAuthorRPC(StartNonce, gs, SubKeyCmd, SubKeySign, *n, client)
+	go authRPC.KeepFresh(ctx)
 	wg := sync.WaitGroup{}
 	start := time.Now()
 	wg.Add(Concurrency)
@@ -110,7 +99,7 @@ func main() {
 				a := NewRandomAnchor()
 				aID := a.AnchorIDHex()
 				// fmt.Println("submitting new anchor with anchor ID", a.AnchorIDHex())
-				res, err := authRPC.SubmitExtrinsic(AnchorCommit, a)
+				res, err := authRPC.SubmitExtrinsic(context.Background(), AnchorCommit, a)
 				if err != nil {
 					fmt.Printf("FAIL!!! anchor ID %s failed with %s\n", aID, err.Error())
 					break
@@ -0,0 +1,47 @@
+package substrate
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the instrumentation name of the spans of this package
+const tracerName = "github.com/vimukthi-git/go-substrate"
+
+// defaultTracer uses the global tracer provider, which doesn't record unless one is registered with
+// otel.SetTracerProvider
+func defaultTracer() trace.Tracer {
+	return otel.GetTracerProvider().Tracer(tracerName)
+}
+
+// endSpan records the error, if any, and ends the span
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// TracingInterceptor starts a client span for every request with the method as the span name, the spans are
+// children of the span in the context of the request
+func TracingInterceptor(tp trace.TracerProvider) Interceptor {
+	tracer := tp.Tracer(tracerName)
+	return func(ctx context.Context, inv *Invocation, next Invoker) error {
+		ctx, span := tracer.Start(ctx, inv.Method, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", inv.Method),
+		))
+		if inv.Kind == BatchInvocation {
+			span.SetAttributes(attribute.Int("rpc.batch_size", len(inv.Batch)))
+		}
+
+		err := next(ctx)
+		endSpan(span, err)
+		return err
+	}
+}
@@ -0,0 +1,106 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/signature"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newTestAuthor returns an author signing with the ed25519 key of Alice, whose signatures are deterministic
+func newTestAuthor(t *testing.T, client Client) *Author {
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	return NewAuthorRPC(0, make([]byte, 32), NewKeyringAccount(pair), *testMetadata(t), client)
+}
+
+func spanNames(spans tracetest.SpanStubs) []string {
+	var names []string
+	for _, s := range spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestAuthor_SubmitExtrinsic_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	m := newMockClient()
+	m.handle("author_submitExtrinsic", func(args ...interface{}) (interface{}, error) {
+		return "0x01", nil
+	})
+	a := newTestAuthor(t, WithInterceptors(m, TracingInterceptor(tp)))
+	a.SetTracerProvider(tp)
+
+	a1, _ := testAnchorCall()
+	res, err := a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x01", res)
+
+	spans := exporter.GetSpans()
+	assert.Equal(t, []string{"metadata.lookup", "extrinsic.encode", "extrinsic.sign", "author_submitExtrinsic",
+		"author_submitExtrinsic", "Author.SubmitExtrinsic"}, spanNames(spans))
+	root := spans[5].SpanContext
+	for _, s := range append(spans[:3:3], spans[4]) {
+		assert.Equal(t, root.TraceID(), s.SpanContext.TraceID())
+		assert.Equal(t, root.SpanID(), s.Parent.SpanID())
+	}
+	// the rpc span is a child of the submission span
+	assert.Equal(t, spans[4].SpanContext.SpanID(), spans[3].Parent.SpanID())
+}
+
+func TestAuthor_SetTracerProvider(t *testing.T) {
+	m := newMockClient()
+	m.handle("author_submitExtrinsic", func(args ...interface{}) (interface{}, error) {
+		return "0x01", nil
+	})
+	a := newTestAuthor(t, m)
+
+	// the provider may be replaced while extrinsics are submitted
+	exporter := tracetest.NewInMemoryExporter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	}()
+	a1, _ := testAnchorCall()
+	_, err := a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	<-done
+
+	_, err = a.SubmitExtrinsic(context.Background(), "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	assert.Contains(t, spanNames(exporter.GetSpans()), "Author.SubmitExtrinsic")
+}
+
+func TestAuthor_SubmitAndWatchExtrinsic_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	conn := &fakeConn{}
+	a := newTestAuthor(t, conn)
+	a.SetTracerProvider(tp)
+
+	a1, _ := testAnchorCall()
+	statuses := make(chan ExtrinsicStatus)
+	_, err := a.SubmitAndWatchExtrinsic(context.Background(), "kerplunk.commit", a1, statuses)
+	assert.NoError(t, err)
+
+	for _, s := range []ExtrinsicStatus{{IsReady: true}, {IsBroadcast: true, AsBroadcast: []string{"peer"}},
+		{IsFinalized: true, AsFinalized: Hash{1, 2}}} {
+		go conn.notify(0, s)
+		assert.Equal(t, s, <-statuses)
+	}
+
+	assert.Eventually(t, func() bool {
+		return len(exporter.GetSpans()) == 8
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, []string{"metadata.lookup", "extrinsic.encode", "extrinsic.sign", "author_submitAndWatchExtrinsic",
+		"extrinsic.status ready", "extrinsic.status broadcast", "extrinsic.status finalized",
+		"Author.SubmitAndWatchExtrinsic"}, spanNames(exporter.GetSpans()))
+	assert.Len(t, exporter.GetSpans()[7].Events, 3)
+}
@@ -0,0 +1,268 @@
+package substrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/crypto/blake2b"
+)
+
+// maxRebroadcasts is the number of times a transaction is submitted again after being dropped from the pool
+const maxRebroadcasts = 3
+
+// errTransactionFinal is returned when replacing a transaction with a final status
+var errTransactionFinal = errors.New("transaction already has a final status")
+
+// ErrTipNotSupported is returned when replacing a transaction of a runtime whose signed extensions don't include
+// ChargeTransactionPayment, the extrinsics can't pay a tip
+var ErrTipNotSupported = errors.New("the signed extensions don't include ChargeTransactionPayment")
+
+// TransactionOutcome is the final status of a transaction. The hash and the tip are the ones of the extrinsic that
+// reached the final status, the replacement if the transaction was replaced.
+type TransactionOutcome struct {
+	Status        ExtrinsicStatus
+	ExtrinsicHash Hash
+	Nonce         uint64
+	Tip           uint64
+}
+
+// Transaction is the handle of a watched extrinsic. It keeps the call and the nonce so that the extrinsic can be
+// replaced by one paying a higher tip, and rebroadcasts the extrinsic when it is dropped from the pool.
+type Transaction struct {
+	author *Author
+	method string
+	args   Args
+	nonce  uint64
+
+	// replace passes the replacements to the watching goroutine
+	replace chan replacement
+	done    chan struct{}
+
+	mu      sync.Mutex
+	current *watchedExtrinsic
+
+	// outcome and err are set once done is closed
+	outcome *TransactionOutcome
+	err     error
+}
+
+// replacement is a request to replace the extrinsic of a transaction with the tip
+type replacement struct {
+	ctx context.Context
+	tip uint64
+	res chan error
+}
+
+// watchedExtrinsic is a submitted extrinsic and the subscription to its statuses
+type watchedExtrinsic struct {
+	hash     Hash
+	tip      uint64
+	sub      Subscription
+	statuses chan ExtrinsicStatus
+}
+
+// SubmitTransaction submits the extrinsic and watches it until a final status or until the context is done. Use
+// Replace to raise its tip and Wait for its outcome.
+func (a *Author) SubmitTransaction(ctx context.Context, method string, args Args) (tx *Transaction, err error) {
+	ctx, span := a.startSpan(ctx, "Author.SubmitTransaction", trace.WithAttributes(attribute.String("substrate.call", method)))
+	defer func() {
+		if err != nil {
+			endSpan(span, err)
+		}
+	}()
+
+	a.mu.RLock()
+	tip := a.tip
+	a.mu.RUnlock()
+	eb, nonce, err := a.encodeWith(ctx, method, args, nil, tip)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := a.submitAndWatch(ctx, eb, tip)
+	a.submitted(nonce, err)
+	if err != nil {
+		return nil, err
+	}
+
+	tx = &Transaction{author: a, method: method, args: args, nonce: nonce, current: w,
+		replace: make(chan replacement), done: make(chan struct{})}
+	go tx.run(ctx, span)
+	return tx, nil
+}
+
+// submitAndWatch submits the encoded extrinsic and subscribes to its statuses
+func (a *Author) submitAndWatch(ctx context.Context, eb string, tip uint64) (*watchedExtrinsic, error) {
+	b, err := hexutil.Decode(eb)
+	if err != nil {
+		return nil, err
+	}
+
+	h := blake2b.Sum256(b)
+	w := &watchedExtrinsic{hash: h[:], tip: tip, statuses: make(chan ExtrinsicStatus)}
+	sctx, span := a.startSpan(ctx, "author_submitAndWatchExtrinsic")
+	w.sub, err = a.client.Subscribe(sctx, ExtrinsicSubscription, w.statuses, eb)
+	endSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Nonce returns the nonce of the transaction, shared by its replacements
+func (t *Transaction) Nonce() uint64 {
+	return t.nonce
+}
+
+// Hash returns the hash of the current extrinsic of the transaction
+func (t *Transaction) Hash() Hash {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current.hash
+}
+
+// Tip returns the tip of the current extrinsic of the transaction
+func (t *Transaction) Tip() uint64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.current.tip
+}
+
+// Replace submits the call again with the same nonce and the higher tip so that it replaces the extrinsic in the pool,
+// eg: when the extrinsic is stuck. The transaction keeps its extrinsic if the replacement is rejected, eg: with
+// ErrCodeTooLowPriority if the tip is not high enough, or with ErrTipNotSupported if the runtime doesn't charge tips.
+func (t *Transaction) Replace(ctx context.Context, tip uint64) error {
+	r := replacement{ctx: ctx, tip: tip, res: make(chan error, 1)}
+	select {
+	case t.replace <- r:
+	case <-t.done:
+		return errTransactionFinal
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return <-r.res
+}
+
+// Wait returns the outcome of the transaction once it has a final status, the error is set unless the transaction
+// is finalized
+func (t *Transaction) Wait(ctx context.Context) (*TransactionOutcome, error) {
+	select {
+	case <-t.done:
+		return t.outcome, t.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// run watches the current extrinsic until a final status, the replacements are submitted here so that the statuses
+// of a replaced extrinsic are not seen. The nonce is synchronised from the chain on the next submission if the
+// transaction failed.
+func (t *Transaction) run(ctx context.Context, span trace.Span) {
+	var err error
+	w := t.current
+	defer func() {
+		w.sub.Unsubscribe()
+		if err != nil {
+			t.author.nonces.invalidate()
+		}
+		t.err = err
+		endSpan(span, err)
+		close(t.done)
+	}()
+
+	rebroadcasts := 0
+	for {
+		select {
+		case s := <-w.statuses:
+			span.AddEvent("status", trace.WithAttributes(attribute.String("substrate.status", s.String()),
+				attribute.String("substrate.extrinsic_hash", w.hash.String())))
+			if s.IsDropped && rebroadcasts < maxRebroadcasts {
+				rebroadcasts++
+				span.AddEvent("rebroadcast")
+				// signed again as the mortal era of the extrinsic may have expired
+				var nw *watchedExtrinsic
+				nw, err = t.submit(ctx, ctx, w.tip)
+				if err != nil {
+					t.outcome = &TransactionOutcome{Status: s, ExtrinsicHash: w.hash, Nonce: t.nonce, Tip: w.tip}
+					return
+				}
+				w = t.swap(w, nw)
+				continue
+			}
+
+			if s.IsFinal() {
+				t.outcome = &TransactionOutcome{Status: s, ExtrinsicHash: w.hash, Nonce: t.nonce, Tip: w.tip}
+				if !s.IsFinalized {
+					err = fmt.Errorf("extrinsic %s", s.String())
+				}
+				return
+			}
+		case r := <-t.replace:
+			nw, rerr := t.replacement(r.ctx, ctx, w, r.tip)
+			if rerr == nil {
+				span.AddEvent("replaced", trace.WithAttributes(attribute.Int64("substrate.tip", int64(r.tip))))
+				w = t.swap(w, nw)
+			}
+			r.res <- rerr
+		case err = <-w.sub.Err():
+			return
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+	}
+}
+
+// replacement submits the call with the nonce of the transaction and the tip, which must be higher than the tip of
+// the current extrinsic. The replacement is watched with the context of the transaction, the subscription ends with it.
+func (t *Transaction) replacement(ctx, watch context.Context, w *watchedExtrinsic, tip uint64) (*watchedExtrinsic, error) {
+	if !t.author.chargesTips() {
+		return nil, ErrTipNotSupported
+	}
+
+	if tip <= w.tip {
+		return nil, fmt.Errorf("tip %d of the replacement is not higher than %d", tip, w.tip)
+	}
+
+	return t.submit(ctx, watch, tip)
+}
+
+// submit signs the call again with the nonce of the transaction, the mortal era is taken from the current reference
+// block of the Author, and watches the extrinsic with the watch context
+func (t *Transaction) submit(ctx, watch context.Context, tip uint64) (*watchedExtrinsic, error) {
+	nonce := t.nonce
+	eb, _, err := t.author.encodeWith(ctx, t.method, t.args, &nonce, tip)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.author.submitAndWatch(watch, eb, tip)
+}
+
+// chargesTips reports whether the signed extensions of the metadata include ChargeTransactionPayment, which encodes
+// the tip
+func (a *Author) chargesTips() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	for _, ext := range a.meta.Extrinsic.SignedExtensions {
+		if ext == "ChargeTransactionPayment" {
+			return true
+		}
+	}
+	return false
+}
+
+// swap unsubscribes from the statuses of the previous extrinsic and makes the next extrinsic the current one
+func (t *Transaction) swap(prev, next *watchedExtrinsic) *watchedExtrinsic {
+	prev.sub.Unsubscribe()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.current = next
+	return next
+}
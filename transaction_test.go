@@ -0,0 +1,158 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/signature"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+	"golang.org/x/crypto/blake2b"
+)
+
+// newTipAuthor creates an Author of //Alice signing the tips of the ChargeTransactionPayment extension of the node
+func newTipAuthor(t *testing.T, n *substratetest.Node, c Client) *Author {
+	n.SetSignedExtensions(testSignedExtensions...)
+	meta := testMetadata(t)
+	meta.Extrinsic.SignedExtensions = testSignedExtensions
+	pair, err := signature.KeyringPairFromURI(Alice, signature.ED25519)
+	assert.NoError(t, err)
+	return NewAuthorRPC(0, n.BlockHash(0), NewKeyringAccount(pair), *meta, c)
+}
+
+func TestTransaction_Replace(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	a := newTipAuthor(t, n, c)
+	a1, _ := testAnchorCall()
+	tx, err := a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), tx.Nonce())
+	original := tx.Hash()
+
+	// the stuck extrinsic is replaced with a higher tip
+	assert.EqualError(t, tx.Replace(ctx, 0), "tip 0 of the replacement is not higher than 0")
+	assert.NoError(t, tx.Replace(ctx, 10))
+	assert.Equal(t, uint64(10), tx.Tip())
+	assert.NotEqual(t, original, tx.Hash())
+	assert.Len(t, n.Pending(), 1)
+
+	block := n.ProduceBlock()
+	outcome, err := tx.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, outcome.Status.IsFinalized)
+	assert.Equal(t, Hash(block), outcome.Status.AsFinalized)
+	assert.Equal(t, tx.Hash(), outcome.ExtrinsicHash)
+	assert.Equal(t, uint64(10), outcome.Tip)
+	assert.Equal(t, errTransactionFinal, tx.Replace(ctx, 20))
+
+	// the extrinsic usurped by another one with the same nonce fails
+	tx, err = a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	other := newTipAuthor(t, n, c)
+	other.nonces.Set(1)
+	other.SetTip(5)
+	res, err := other.SubmitExtrinsic(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	outcome, err = tx.Wait(ctx)
+	assert.EqualError(t, err, "extrinsic usurped")
+	assert.Equal(t, res, outcome.Status.AsUsurped.String())
+
+	// the nonce is synchronised from the chain after the failure
+	next, err := a.nonces.Next(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), next)
+}
+
+func TestTransaction_Replace_NoTip(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the extrinsics without the ChargeTransactionPayment extension have no tip to raise
+	ctx := context.Background()
+	a := newTipAuthor(t, n, c)
+	a.meta.Extrinsic.SignedExtensions = []string{"CheckSpecVersion", "CheckTxVersion", "CheckGenesis", "CheckMortality",
+		"CheckNonce", "CheckWeight"}
+	n.SetSignedExtensions(a.meta.Extrinsic.SignedExtensions...)
+	a1, _ := testAnchorCall()
+	tx, err := a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	original := tx.Hash()
+	assert.Equal(t, ErrTipNotSupported, tx.Replace(ctx, 10))
+	assert.Equal(t, original, tx.Hash())
+	assert.Len(t, n.Pending(), 1)
+
+	n.ProduceBlock()
+	outcome, err := tx.Wait(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, original, outcome.ExtrinsicHash)
+}
+
+func TestTransaction_Rebroadcast(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	ctx := context.Background()
+	a := newTipAuthor(t, n, c)
+	a1, _ := testAnchorCall()
+	tx, err := a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	hash := tx.Hash()
+
+	// the dropped extrinsic is submitted again
+	drop := func() {
+		assert.True(t, n.Drop(hash))
+		assert.Eventually(t, func() bool {
+			return len(n.Pending()) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+	for i := 0; i < maxRebroadcasts; i++ {
+		drop()
+	}
+	assert.Equal(t, hash, tx.Hash())
+	n.ProduceBlock()
+	outcome, err := tx.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, outcome.Status.IsFinalized)
+	assert.Equal(t, hash, outcome.ExtrinsicHash)
+
+	tx, err = a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	hash = tx.Hash()
+	for i := 0; i < maxRebroadcasts; i++ {
+		drop()
+	}
+	assert.True(t, n.Drop(hash))
+	outcome, err = tx.Wait(ctx)
+	assert.EqualError(t, err, "extrinsic dropped")
+	assert.True(t, outcome.Status.IsDropped)
+	assert.Empty(t, n.Pending())
+
+	// the rebroadcast is signed again with the nonce of the transaction and the era of the best block, the future
+	// nonce keeps the extrinsic out of the blocks
+	a.SetMortality(64)
+	a.nonces.Set(5)
+	tx, err = a.SubmitTransaction(ctx, "kerplunk.commit", a1)
+	assert.NoError(t, err)
+	hash = tx.Hash()
+	for i := 0; i < 3; i++ {
+		n.ProduceBlock()
+	}
+	drop()
+	assert.NotEqual(t, hash, tx.Hash())
+	assert.Equal(t, uint64(5), tx.Nonce())
+	h := blake2b.Sum256(n.Pending()[0])
+	assert.Equal(t, Hash(h[:]), tx.Hash())
+}
@@ -0,0 +1,545 @@
+package substrate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+)
+
+// TypeDecoder decodes a single value of a metadata type from the stream.
+// Like the scalecodec decoders it panics when the stream can't be decoded.
+type TypeDecoder func(decoder scalecodec.Decoder) interface{}
+
+// ArgsFactory creates an empty, decodable Args value for a call
+type ArgsFactory func() Args
+
+// Arg is a single call argument decoded using the type name from FunctionMetaData.Args
+type Arg struct {
+	Name  string
+	Type  string
+	Value interface{}
+}
+
+// DynamicArgs are call arguments decoded from the metadata when no Go type is registered for the call.
+// The original encoding is kept so that the arguments can be encoded again as is.
+type DynamicArgs struct {
+	Args []Arg
+	raw  []byte
+}
+
+// Get returns the value of the argument with the given name
+func (d DynamicArgs) Get(name string) (interface{}, bool) {
+	for _, a := range d.Args {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return nil, false
+}
+
+func (d DynamicArgs) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(d.raw)
+}
+
+// RawArgs are the undecoded call arguments, used when the argument types are unknown to the TypeRegistry
+type RawArgs []byte
+
+func (r RawArgs) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(r)
+}
+
+// AccountIndex is the short form of an account address
+type AccountIndex uint64
+
+var (
+	// reTypeQualifier matches trait qualifiers such as `<T::Lookup as StaticLookup>::`
+	reTypeQualifier = regexp.MustCompile(`<[\w:]+ as [\w:]+>::`)
+	reGeneric       = regexp.MustCompile(`^(\w+)<(.+)>$`)
+	reFixedArray    = regexp.MustCompile(`^\[(.+);(\d+)\]$`)
+)
+
+// TypeRegistry maps metadata type names and call names to decoders, and the signed extension identifiers to their
+// implementation
+type TypeRegistry struct {
+	mu         sync.RWMutex
+	types      map[string]TypeDecoder
+	calls      map[string]ArgsFactory
+	extensions map[string]SignedExtension
+}
+
+// NewTypeRegistry returns a registry populated with the primitive and common runtime types and the substrate
+// signed extensions
+func NewTypeRegistry() *TypeRegistry {
+	r := &TypeRegistry{types: make(map[string]TypeDecoder), calls: make(map[string]ArgsFactory),
+		extensions: make(map[string]SignedExtension)}
+	for n, ext := range defaultSignedExtensions {
+		r.extensions[n] = ext
+	}
+	for _, n := range []string{"u8", "u16", "u32", "u64", "i8", "i16", "i32", "i64", "bool"} {
+		r.types[n] = primitiveDecoder(n)
+	}
+	r.types["u128"] = decodeU128
+	r.types["Balance"] = decodeU128
+	r.types["Hash"] = decodeHash
+	r.types["H256"] = decodeHash
+	r.types["AccountId"] = decodeAccountID
+	r.types["SessionKey"] = decodeAccountID
+	r.types["Address"] = decodeAddress
+	r.types["Source"] = decodeAddress
+	r.types["AccountIndex"] = primitiveDecoder("u32")
+	r.types["Moment"] = primitiveDecoder("u64")
+	r.types["Signature"] = decodeSignature
+	r.types["Bytes"] = decodeBytes
+	r.types["Key"] = decodeBytes
+	r.types["Text"] = decodeText
+	r.types["String"] = decodeText
+	r.types["KeyValue"] = func(decoder scalecodec.Decoder) interface{} {
+		return []interface{}{decodeBytes(decoder), decodeBytes(decoder)}
+	}
+	return r
+}
+
+// RegisterType registers a decoder for the given metadata type name, eg: "T::Balance" or "Balance". The registered
+// decoder takes precedence over the layout of the metadata version, eg: of a runtime with a custom Weight.
+func (r *TypeRegistry) RegisterType(name string, decoder TypeDecoder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[normalizeType(name)] = decoder
+}
+
+// RegisterCall registers a Go type for the arguments of a call, eg: "kerplunk.commit".
+// The value returned by factory must be a pointer that implements scalecodec.Decodeable.
+func (r *TypeRegistry) RegisterCall(name string, factory ArgsFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.calls[name] = factory
+}
+
+func (r *TypeRegistry) typeDecoder(name string) (TypeDecoder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.types[name]
+	return d, ok
+}
+
+func (r *TypeRegistry) callFactory(name string) (ArgsFactory, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.calls[name]
+	return f, ok
+}
+
+// DecodeExtrinsic decodes a length prefixed extrinsic of any version, signed or unsigned (inherent), with its call
+// arguments
+func (r *TypeRegistry) DecodeExtrinsic(b []byte, meta *MetadataVersioned) (e *Extrinsic, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode extrinsic: %v", rec)
+		}
+	}()
+
+	c := r.newCallDecoder(b, meta)
+	// length of the extrinsic, the remaining bytes are the extrinsic itself
+	l := c.decoder.DecodeUintCompact()
+	if l != uint64(c.reader.Len()) {
+		return nil, fmt.Errorf("extrinsic length %d does not match the encoded length %d", l, c.reader.Len())
+	}
+
+	e = &Extrinsic{encoded: b, Version: c.decoder.ReadOneByte()}
+	if e.IsSigned() && len(meta.Extrinsic.SignedExtensions) > 0 {
+		c.signature(e)
+	} else if e.IsSigned() {
+		e.Signature.decode(c.decoder, e.FormatVersion())
+		e.Nonce = e.Signature.Nonce
+		e.Era = e.Signature.Era
+	}
+
+	e.Method = c.method(true)
+	return e, nil
+}
+
+// signature decodes the signature of the extrinsic with the extra data of the signed extensions of the metadata
+func (c *callDecoder) signature(e *Extrinsic) {
+	exts, err := c.registry.signedExtensions(c.meta.Extrinsic.SignedExtensions)
+	if err != nil {
+		panic(err)
+	}
+
+	s := &e.Signature
+	s.decodeSigner(c.decoder, e.FormatVersion())
+
+	start := c.offset()
+	var params ExtensionParams
+	for _, ext := range exts {
+		ext.DecodeExtra(c.decoder, &params)
+	}
+	s.Extra = c.b[start:c.offset()]
+	s.Nonce = params.Nonce
+	s.Era = params.Era
+
+	e.Extensions = exts
+	e.Nonce = params.Nonce
+	e.Era = params.Era
+	e.Tip = params.Tip
+}
+
+// DecodeMethod decodes an encoded call, see TypeRegistry.DecodeExtrinsic
+func (r *TypeRegistry) DecodeMethod(b []byte, meta *MetadataVersioned) (m Method, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode method: %v", rec)
+		}
+	}()
+
+	return r.newCallDecoder(b, meta).method(true), nil
+}
+
+// callDecoder decodes calls from an encoded extrinsic keeping track of the offset to retain the raw arguments
+type callDecoder struct {
+	registry *TypeRegistry
+	meta     *MetadataVersioned
+	b        []byte
+	reader   *bytes.Reader
+	decoder  scalecodec.Decoder
+}
+
+func (r *TypeRegistry) newCallDecoder(b []byte, meta *MetadataVersioned) *callDecoder {
+	reader := bytes.NewReader(b)
+	return &callDecoder{registry: r, meta: meta, b: b, reader: reader, decoder: *scalecodec.NewDecoder(reader)}
+}
+
+func (c *callDecoder) offset() int {
+	return len(c.b) - c.reader.Len()
+}
+
+// method decodes the call index and the call arguments. Arguments are decoded in to the type registered
+// using RegisterCall or else in to DynamicArgs using FunctionMetaData.Args. If the arguments of an outermost
+// call can't be decoded they are returned as RawArgs.
+func (c *callDecoder) method(outermost bool) Method {
+	m := Method{}
+	c.decoder.Decode(&m.CallIndex)
+	mod, call, err := c.meta.Metadata.FindCall(m.CallIndex)
+	if err != nil {
+		panic(err)
+	}
+
+	if f, ok := c.registry.callFactory(mod.Name + "." + call.Name); ok {
+		args := f()
+		c.decoder.Decode(args)
+		m.Args = args
+		return m
+	}
+
+	start := c.offset()
+	args, err := c.args(call.Args)
+	if err != nil {
+		if !outermost {
+			panic(err)
+		}
+		// outermost call arguments span till the end of the extrinsic
+		m.Args = RawArgs(c.b[start:])
+		c.reader.Seek(0, io.SeekEnd)
+		return m
+	}
+
+	args.raw = c.b[start:c.offset()]
+	m.Args = args
+	return m
+}
+
+func (c *callDecoder) args(argsMeta []FunctionArgumentMetadata) (args DynamicArgs, err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("failed to decode call arguments: %v", rec)
+		}
+	}()
+
+	args = DynamicArgs{Args: make([]Arg, 0, len(argsMeta))}
+	for _, a := range argsMeta {
+		args.Args = append(args.Args, Arg{Name: a.Name, Type: a.Type, Value: c.value(a.Type)})
+	}
+	return args, nil
+}
+
+// value decodes a value of the given metadata type, panics if the type is not supported
+func (c *callDecoder) value(typ string) interface{} {
+	typ = normalizeType(typ)
+	if d, ok := c.registry.typeDecoder(typ); ok {
+		return d(c.decoder)
+	}
+
+	if d, ok := runtimeTypeDecoder(typ, c.meta.Version); ok {
+		return d(c.decoder)
+	}
+
+	switch typ {
+	case "Call", "Proposal":
+		// nested calls eg: sudo.sudo
+		return c.method(false)
+	}
+
+	if m := reFixedArray.FindStringSubmatch(typ); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		if m[1] == "u8" {
+			b := make([]byte, n)
+			c.decoder.Read(b)
+			return b
+		}
+		return c.values(m[1], n)
+	}
+
+	if strings.HasPrefix(typ, "(") && strings.HasSuffix(typ, ")") {
+		elems := splitTypes(typ[1 : len(typ)-1])
+		values := make([]interface{}, 0, len(elems))
+		for _, t := range elems {
+			values = append(values, c.value(t))
+		}
+		return values
+	}
+
+	m := reGeneric.FindStringSubmatch(typ)
+	if m == nil {
+		panic(fmt.Sprintf("unsupported type %s", typ))
+	}
+
+	switch m[1] {
+	case "Vec":
+		if m[2] == "u8" {
+			return decodeBytes(c.decoder)
+		}
+		return c.values(m[2], int(c.decoder.DecodeUintCompact()))
+	case "Option":
+		if c.decoder.ReadOneByte() == 0 {
+			return nil
+		}
+		return c.value(m[2])
+	case "Compact":
+		return c.decoder.DecodeUintCompact()
+	case "Box":
+		return c.value(m[2])
+	}
+
+	panic(fmt.Sprintf("unsupported type %s", typ))
+}
+
+func (c *callDecoder) values(typ string, n int) []interface{} {
+	values := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		values = append(values, c.value(typ))
+	}
+	return values
+}
+
+// runtimeTypeDecoder returns the decoder of the types whose layout changed with the runtimes. The runtimes of the
+// metadata v11 and v12 have u32 indexes and block numbers, a u64 weight and the DispatchError enum.
+func runtimeTypeDecoder(typ string, version uint8) (TypeDecoder, bool) {
+	v11 := version >= 11
+	switch typ {
+	case "Index", "BlockNumber":
+		if v11 {
+			return primitiveDecoder("u32"), true
+		}
+		return primitiveDecoder("u64"), true
+	case "Weight":
+		if v11 {
+			return primitiveDecoder("u64"), true
+		}
+		return primitiveDecoder("u32"), true
+	case "DispatchError":
+		return func(decoder scalecodec.Decoder) interface{} {
+			d := DispatchError{}
+			if v11 {
+				d.decodeV11(decoder)
+			} else {
+				decoder.Decode(&d)
+			}
+			return d
+		}, true
+	case "DispatchInfo":
+		return func(decoder scalecodec.Decoder) interface{} {
+			d := DispatchInfo{}
+			if v11 {
+				d.decodeV11(decoder)
+			} else {
+				decoder.Decode(&d)
+			}
+			return d
+		}, true
+	}
+	return nil, false
+}
+
+// normalizeType strips the trait qualifiers from a metadata type name, eg: T::Hash => Hash
+func normalizeType(typ string) string {
+	typ = strings.Replace(typ, "T::", "", -1)
+	typ = reTypeQualifier.ReplaceAllString(typ, "")
+	typ = strings.Replace(typ, " ", "", -1)
+	typ = strings.Replace(typ, "\n", "", -1)
+	return typ
+}
+
+// splitTypes splits a comma separated list of types ignoring the commas within generics
+func splitTypes(s string) []string {
+	var res []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '<', '(', '[':
+			depth++
+		case '>', ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				res = append(res, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if start < len(s) {
+		res = append(res, s[start:])
+	}
+	return res
+}
+
+func primitiveDecoder(typ string) TypeDecoder {
+	return func(decoder scalecodec.Decoder) interface{} {
+		switch typ {
+		case "bool":
+			var v bool
+			decoder.Decode(&v)
+			return v
+		case "u8":
+			var v uint8
+			decoder.Decode(&v)
+			return v
+		case "u16":
+			var v uint16
+			decoder.Decode(&v)
+			return v
+		case "u32":
+			var v uint32
+			decoder.Decode(&v)
+			return v
+		case "u64":
+			var v uint64
+			decoder.Decode(&v)
+			return v
+		case "i8":
+			var v int8
+			decoder.Decode(&v)
+			return v
+		case "i16":
+			var v int16
+			decoder.Decode(&v)
+			return v
+		case "i32":
+			var v int32
+			decoder.Decode(&v)
+			return v
+		case "i64":
+			var v int64
+			decoder.Decode(&v)
+			return v
+		}
+		panic(fmt.Sprintf("unsupported type %s", typ))
+	}
+}
+
+// decodeU128 decodes a little endian u128 in to a big.Int
+func decodeU128(decoder scalecodec.Decoder) interface{} {
+	b := make([]byte, 16)
+	decoder.Read(b)
+	// big.Int expects big endian
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return new(big.Int).SetBytes(b)
+}
+
+func decodeHash(decoder scalecodec.Decoder) interface{} {
+	h := make(Hash, 32)
+	decoder.Read(h)
+	return h
+}
+
+func decodeAccountID(decoder scalecodec.Decoder) interface{} {
+	a := Address{}
+	decoder.Decode(&a)
+	return a
+}
+
+// decodeAddress decodes the address representations from Address.decodeAddress, either an account ID or an index
+func decodeAddress(decoder scalecodec.Decoder) interface{} {
+	b := decoder.ReadOneByte()
+	switch b {
+	case 0xff:
+		return decodeAccountID(decoder)
+	case 0xfc:
+		buf := make([]byte, 2)
+		decoder.Read(buf)
+		return AccountIndex(binary.LittleEndian.Uint16(buf))
+	case 0xfd:
+		buf := make([]byte, 4)
+		decoder.Read(buf)
+		return AccountIndex(binary.LittleEndian.Uint32(buf))
+	case 0xfe:
+		buf := make([]byte, 8)
+		decoder.Read(buf)
+		return AccountIndex(binary.LittleEndian.Uint64(buf))
+	}
+
+	// the prefixes from 0xf0 are reserved
+	if b >= 0xf0 {
+		panic(fmt.Sprintf("invalid address prefix %#x", b))
+	}
+	return AccountIndex(b)
+}
+
+// encodeAccountIndex encodes the index in the representation read by decodeAddress
+func encodeAccountIndex(encoder scalecodec.Encoder, index AccountIndex) {
+	switch {
+	case index < 0xf0:
+		encoder.PushByte(byte(index))
+	case index <= 0xffff:
+		buf := make([]byte, 2)
+		binary.LittleEndian.PutUint16(buf, uint16(index))
+		encoder.PushByte(0xfc)
+		encoder.Write(buf)
+	case index <= 0xffffffff:
+		buf := make([]byte, 4)
+		binary.LittleEndian.PutUint32(buf, uint32(index))
+		encoder.PushByte(0xfd)
+		encoder.Write(buf)
+	default:
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint64(buf, uint64(index))
+		encoder.PushByte(0xfe)
+		encoder.Write(buf)
+	}
+}
+
+func decodeSignature(decoder scalecodec.Decoder) interface{} {
+	s := Signature{}
+	decoder.Decode(&s)
+	return s
+}
+
+func decodeBytes(decoder scalecodec.Decoder) interface{} {
+	var b []byte
+	decoder.Decode(&b)
+	return b
+}
+
+func decodeText(decoder scalecodec.Decoder) interface{} {
+	var s string
+	decoder.Decode(&s)
+	return s
+}
@@ -0,0 +1,328 @@
+package substrate
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/scalecodec"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+type testAnchorParams struct {
+	AnchorIDPreimage [32]byte
+	DocRoot          [32]byte
+	Proof            [32]byte
+}
+
+func (a *testAnchorParams) ParityDecode(decoder scalecodec.Decoder) {
+	decoder.Read(a.AnchorIDPreimage[:])
+	decoder.Read(a.DocRoot[:])
+	decoder.Read(a.Proof[:])
+}
+
+func (a testAnchorParams) ParityEncode(encoder scalecodec.Encoder) {
+	encoder.Write(a.AnchorIDPreimage[:])
+	encoder.Write(a.DocRoot[:])
+	encoder.Write(a.Proof[:])
+}
+
+func testMetadata(t *testing.T) *MetadataVersioned {
+	b, err := hexutil.Decode(substratetest.MetadataV4)
+	assert.NoError(t, err)
+	meta := NewMetadataVersioned()
+	scalecodec.NewDecoder(bytes.NewReader(b)).Decode(meta)
+	return meta
+}
+
+// encodeTestExtrinsic encodes an extrinsic with a length prefix, signed when sig is not nil
+func encodeTestExtrinsic(sig *ExtrinsicSignature, call []byte) []byte {
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	if sig != nil {
+		enc.PushByte(ExtrinsicBitSigned | ExtrinsicVersion1)
+		sig.encode(*enc, ExtrinsicVersion1)
+	} else {
+		enc.PushByte(ExtrinsicVersion1)
+	}
+	enc.Write(call)
+
+	var res bytes.Buffer
+	enc = scalecodec.NewEncoder(&res)
+	enc.EncodeUintCompact(uint64(bb.Len()))
+	enc.Write(bb.Bytes())
+	return res.Bytes()
+}
+
+func testAnchorCall() (testAnchorParams, []byte) {
+	a := testAnchorParams{}
+	a.AnchorIDPreimage[0] = 1
+	a.DocRoot[0] = 2
+	a.Proof[0] = 3
+	var bb bytes.Buffer
+	// kerplunk.commit
+	bb.Write([]byte{5, 0})
+	scalecodec.NewEncoder(&bb).Encode(a)
+	return a, bb.Bytes()
+}
+
+func TestMetadataV4_FindCall(t *testing.T) {
+	meta := testMetadata(t)
+	idx := meta.Metadata.MethodIndex("kerplunk.commit")
+	mod, call, err := meta.Metadata.FindCall(idx)
+	assert.NoError(t, err)
+	assert.Equal(t, "kerplunk", mod.Name)
+	assert.Equal(t, "commit", call.Name)
+
+	_, _, err = meta.Metadata.FindCall(MethodIDX{SectionIndex: 100})
+	assert.Error(t, err)
+}
+
+func TestTypeRegistry_DecodeExtrinsic_Signed(t *testing.T) {
+	meta := testMetadata(t)
+	a, call := testAnchorCall()
+	sig := NewExtrinsicSignature(Signature{}, 12)
+	b := encodeTestExtrinsic(&sig, call)
+
+	// dynamic arguments
+	r := NewTypeRegistry()
+	e, err := r.DecodeExtrinsic(b, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(0x81), e.Version)
+	assert.True(t, e.IsSigned())
+	assert.Equal(t, uint64(12), e.Nonce)
+	args, ok := e.Method.Args.(DynamicArgs)
+	assert.True(t, ok)
+	assert.Len(t, args.Args, 3)
+	v, ok := args.Get("doc_root")
+	assert.True(t, ok)
+	assert.Equal(t, Hash(a.DocRoot[:]), v)
+
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e.Method)
+	assert.Equal(t, call, bb.Bytes())
+
+	// typed arguments
+	r.RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+	e, err = r.DecodeExtrinsic(b, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, &a, e.Method.Args)
+}
+
+func TestTypeRegistry_DecodeExtrinsic_Unsigned(t *testing.T) {
+	meta := testMetadata(t)
+	var bb bytes.Buffer
+	// timestamp.set
+	bb.Write([]byte{0, 0})
+	scalecodec.NewEncoder(&bb).EncodeUintCompact(1562000000)
+	b := encodeTestExtrinsic(nil, bb.Bytes())
+
+	e, err := NewTypeRegistry().DecodeExtrinsic(b, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, uint8(ExtrinsicVersion1), e.Version)
+	assert.False(t, e.IsSigned())
+	v, ok := e.Method.Args.(DynamicArgs).Get("now")
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1562000000), v)
+}
+
+func TestTypeRegistry_DecodeExtrinsic_Versions(t *testing.T) {
+	meta := testMetadata(t)
+	a, _ := testAnchorCall()
+	r := NewTypeRegistry()
+	r.RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+
+	for _, version := range []uint8{ExtrinsicVersion1, ExtrinsicVersion2, ExtrinsicVersion3, ExtrinsicVersion4} {
+		e := NewExtrinsic(Account{}, 7, nil, NewMethod("kerplunk.commit", &a, *meta))
+		e.Version = ExtrinsicBitSigned | version
+		e.Signature = NewExtrinsicSignature(Signature{Hash: [64]byte{9}}, 7)
+		e.Signature.Signer = *NewAddress([]byte{3})
+		e.Signature.SignatureType = MultiSignatureSr25519
+		var bb bytes.Buffer
+		scalecodec.NewEncoder(&bb).Encode(e)
+
+		d, err := r.DecodeExtrinsic(bb.Bytes(), meta)
+		assert.NoError(t, err)
+		assert.Equal(t, version, d.FormatVersion())
+		assert.True(t, d.IsSigned())
+		assert.Equal(t, uint64(7), d.Nonce)
+		assert.Equal(t, e.Signature.Signer, d.Signature.Signer)
+		assert.Equal(t, e.Signature.Signature, d.Signature.Signature)
+		assert.Equal(t, &a, d.Method.Args)
+
+		var db bytes.Buffer
+		scalecodec.NewEncoder(&db).Encode(d)
+		assert.Equal(t, bb.Bytes(), db.Bytes())
+	}
+
+	// the signature type is only encoded from version 4
+	e := NewExtrinsic(Account{}, 7, nil, NewMethod("kerplunk.commit", &a, *meta))
+	e.Version = ExtrinsicBitSigned | ExtrinsicVersion4
+	e.Signature.SignatureType = 3
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e)
+	_, err := r.DecodeExtrinsic(bb.Bytes(), meta)
+	assert.EqualError(t, err, "failed to decode extrinsic: unsupported signature type 3")
+}
+
+func TestTypeRegistry_DecodeExtrinsic_Signers(t *testing.T) {
+	meta := testMetadata(t)
+	a, _ := testAnchorCall()
+	r := NewTypeRegistry()
+	r.RegisterCall("kerplunk.commit", func() Args {
+		return &testAnchorParams{}
+	})
+
+	// the ECDSA signatures have 65 bytes and the signers may be addressed by their index
+	var ecdsa [65]byte
+	ecdsa[0], ecdsa[64] = 1, 27
+	for _, index := range []AccountIndex{3, 0xf0, 0x10000, 0x100000000} {
+		for _, st := range []uint8{MultiSignatureEd25519, MultiSignatureSr25519, MultiSignatureEcdsa} {
+			e := NewExtrinsic(Account{}, 7, nil, NewMethod("kerplunk.commit", &a, *meta))
+			e.Version = ExtrinsicBitSigned | ExtrinsicVersion4
+			e.Signature = NewExtrinsicSignature(Signature{Hash: [64]byte{9}}, 7)
+			e.Signature.SignerIndex = &index
+			e.Signature.SignatureType = st
+			if st == MultiSignatureEcdsa {
+				e.Signature.Signature = Signature{}
+				e.Signature.EcdsaSignature = ecdsa
+			}
+			var bb bytes.Buffer
+			scalecodec.NewEncoder(&bb).Encode(e)
+
+			d, err := r.DecodeExtrinsic(bb.Bytes(), meta)
+			assert.NoError(t, err)
+			assert.Equal(t, index, *d.Signature.SignerIndex)
+			assert.Equal(t, st, d.Signature.SignatureType)
+			assert.Equal(t, e.Signature.Signature, d.Signature.Signature)
+			assert.Equal(t, e.Signature.EcdsaSignature, d.Signature.EcdsaSignature)
+			assert.Equal(t, uint64(7), d.Nonce)
+			assert.Equal(t, &a, d.Method.Args)
+
+			var db bytes.Buffer
+			scalecodec.NewEncoder(&db).Encode(d)
+			assert.Equal(t, bb.Bytes(), db.Bytes())
+		}
+	}
+
+	// the prefixes from 0xf0 other than the index and account prefixes are reserved
+	for p := 0xf0; p < 0xfc; p++ {
+		assert.Panics(t, func() {
+			decodeAddress(*scalecodec.NewDecoder(bytes.NewReader([]byte{byte(p), 0, 0, 0, 0, 0, 0, 0, 0})))
+		})
+	}
+	index := AccountIndex(3)
+	e := NewExtrinsic(Account{}, 7, nil, NewMethod("kerplunk.commit", &a, *meta))
+	e.Version = ExtrinsicBitSigned | ExtrinsicVersion4
+	e.Signature.SignerIndex = &index
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e)
+	b := bb.Bytes()
+	// the length prefix of 2 bytes and the version precede the signer
+	assert.Equal(t, byte(3), b[3])
+	b[3] = 0xf1
+	_, err := r.DecodeExtrinsic(b, meta)
+	assert.EqualError(t, err, "failed to decode extrinsic: invalid address prefix 0xf1")
+}
+
+func TestExtrinsic_Unsigned(t *testing.T) {
+	meta := testMetadata(t)
+	a, call := testAnchorCall()
+	e := NewUnsignedExtrinsic(ExtrinsicVersion4, NewMethod("kerplunk.commit", &a, *meta))
+	assert.False(t, e.IsSigned())
+
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(e)
+	var expected bytes.Buffer
+	scalecodec.NewEncoder(&expected).EncodeUintCompact(uint64(len(call) + 1))
+	expected.WriteByte(ExtrinsicVersion4)
+	expected.Write(call)
+	assert.Equal(t, expected.Bytes(), bb.Bytes())
+
+	// the extrinsic can't be decoded past its length
+	d := Extrinsic{Method: Method{Args: &testAnchorParams{}}}
+	d.ParityDecode(*scalecodec.NewDecoder(bytes.NewReader(append(bb.Bytes(), 1, 2))))
+	assert.Equal(t, uint8(ExtrinsicVersion4), d.Version)
+	assert.Equal(t, &a, d.Method.Args)
+	assert.Equal(t, bb.Bytes(), []byte(d.encoded))
+
+	assert.Panics(t, func() {
+		b := append([]byte{}, bb.Bytes()...)
+		b[0] -= 4
+		d := Extrinsic{Method: Method{Args: &testAnchorParams{}}}
+		d.ParityDecode(*scalecodec.NewDecoder(bytes.NewReader(append(b, 1))))
+	})
+}
+
+func TestTypeRegistry_DecodeMethod(t *testing.T) {
+	meta := testMetadata(t)
+	r := NewTypeRegistry()
+
+	// balances.transfer
+	var bb bytes.Buffer
+	enc := scalecodec.NewEncoder(&bb)
+	bb.Write([]byte{3, 0})
+	enc.Encode(Address{PubKey: [32]byte{1}})
+	enc.EncodeUintCompact(1000)
+	m, err := r.DecodeMethod(bb.Bytes(), meta)
+	assert.NoError(t, err)
+	dest, _ := m.Args.(DynamicArgs).Get("dest")
+	assert.Equal(t, Address{PubKey: [32]byte{1}}, dest)
+	value, _ := m.Args.(DynamicArgs).Get("value")
+	assert.Equal(t, uint64(1000), value)
+
+	// sudo.sudo with a nested call
+	_, call := testAnchorCall()
+	m, err = r.DecodeMethod(append([]byte{4, 0}, call...), meta)
+	assert.NoError(t, err)
+	proposal, _ := m.Args.(DynamicArgs).Get("proposal")
+	assert.Equal(t, MethodIDX{5, 0}, proposal.(Method).CallIndex)
+
+	// unsupported types are kept raw
+	r.RegisterType("u64", func(decoder scalecodec.Decoder) interface{} {
+		panic("unsupported")
+	})
+	m, err = r.DecodeMethod([]byte{1, 3, 1, 0, 0, 0, 0, 0, 0, 0}, meta)
+	assert.NoError(t, err)
+	assert.Equal(t, RawArgs{1, 0, 0, 0, 0, 0, 0, 0}, m.Args)
+}
+
+func TestRawArgs_RoundTrip(t *testing.T) {
+	meta := testMetadata(t)
+	r := NewTypeRegistry()
+	r.RegisterType("u64", func(decoder scalecodec.Decoder) interface{} {
+		panic("unsupported")
+	})
+
+	// the raw arguments are encoded as decoded, without a length prefix
+	call := []byte{1, 3, 1, 0, 0, 0, 0, 0, 0, 0}
+	m, err := r.DecodeMethod(call, meta)
+	assert.NoError(t, err)
+	var bb bytes.Buffer
+	scalecodec.NewEncoder(&bb).Encode(m)
+	assert.Equal(t, call, bb.Bytes())
+
+	sig := NewExtrinsicSignature(Signature{}, 3)
+	b := encodeTestExtrinsic(&sig, call)
+	e, err := r.DecodeExtrinsic(b, meta)
+	assert.NoError(t, err)
+	assert.IsType(t, RawArgs{}, e.Method.Args)
+	bb.Reset()
+	scalecodec.NewEncoder(&bb).Encode(e)
+	assert.Equal(t, b, bb.Bytes())
+}
+
+func TestTypeRegistry_U128(t *testing.T) {
+	meta := testMetadata(t)
+	r := NewTypeRegistry()
+	c := r.newCallDecoder([]byte{1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}, meta)
+	v := c.value("T::Balance")
+	expected, _ := new(big.Int).SetString("1329227995784915872903807060280344577", 10)
+	assert.Equal(t, expected, v)
+}
@@ -0,0 +1,167 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ExtrinsicStatus is a status of an extrinsic in the transaction pool, sent by author_submitAndWatchExtrinsic
+type ExtrinsicStatus struct {
+	IsFuture    bool
+	IsReady     bool
+	IsBroadcast bool
+	// AsBroadcast are the peers the extrinsic was broadcast to
+	AsBroadcast []string
+	IsFinalized bool
+	// AsFinalized is the hash of the block including the extrinsic
+	AsFinalized Hash
+	IsUsurped   bool
+	// AsUsurped is the hash of the extrinsic replacing this one
+	AsUsurped Hash
+	IsDropped bool
+	IsInvalid bool
+}
+
+func (s *ExtrinsicStatus) UnmarshalJSON(b []byte) error {
+	var name string
+	if err := json.Unmarshal(b, &name); err == nil {
+		switch name {
+		case "future":
+			s.IsFuture = true
+		case "ready":
+			s.IsReady = true
+		case "dropped":
+			s.IsDropped = true
+		case "invalid":
+			s.IsInvalid = true
+		default:
+			return fmt.Errorf("unknown extrinsic status %s", name)
+		}
+		return nil
+	}
+
+	var v struct {
+		Broadcast *[]string `json:"broadcast"`
+		Finalized Hash      `json:"finalized"`
+		Usurped   Hash      `json:"usurped"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+
+	switch {
+	case v.Broadcast != nil:
+		s.IsBroadcast = true
+		s.AsBroadcast = *v.Broadcast
+	case v.Finalized != nil:
+		s.IsFinalized = true
+		s.AsFinalized = v.Finalized
+	case v.Usurped != nil:
+		s.IsUsurped = true
+		s.AsUsurped = v.Usurped
+	default:
+		return fmt.Errorf("unknown extrinsic status %s", string(b))
+	}
+	return nil
+}
+
+func (s ExtrinsicStatus) String() string {
+	switch {
+	case s.IsFuture:
+		return "future"
+	case s.IsReady:
+		return "ready"
+	case s.IsBroadcast:
+		return "broadcast"
+	case s.IsFinalized:
+		return "finalized"
+	case s.IsUsurped:
+		return "usurped"
+	case s.IsDropped:
+		return "dropped"
+	case s.IsInvalid:
+		return "invalid"
+	default:
+		return "unknown"
+	}
+}
+
+// IsFinal reports whether no further status follows
+func (s ExtrinsicStatus) IsFinal() bool {
+	return s.IsFinalized || s.IsUsurped || s.IsDropped || s.IsInvalid
+}
+
+// SubmitAndWatchExtrinsic submits the extrinsic and sends its status changes to the channel until a final status,
+// the context is done or the subscription is unsubscribed. The time spent in every status is traced.
+func (a *Author) SubmitAndWatchExtrinsic(ctx context.Context, method string, args Args, statuses chan<- ExtrinsicStatus) (sub Subscription, err error) {
+	ctx, span := a.startSpan(ctx, "Author.SubmitAndWatchExtrinsic", trace.WithAttributes(attribute.String("substrate.call", method)))
+	defer func() {
+		if err != nil {
+			endSpan(span, err)
+		}
+	}()
+
+	eb, nonce, err := a.encodeExtrinsic(ctx, method, args)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan ExtrinsicStatus)
+	sctx, sspan := a.startSpan(ctx, "author_submitAndWatchExtrinsic")
+	sub, err = a.client.Subscribe(sctx, ExtrinsicSubscription, ch, eb)
+	endSpan(sspan, err)
+	a.submitted(nonce, err)
+	if err != nil {
+		return nil, err
+	}
+
+	go a.watch(ctx, span, sub, ch, statuses)
+	return sub, nil
+}
+
+// watch forwards the statuses with a span per status, span is the span of the submission
+func (a *Author) watch(ctx context.Context, span trace.Span, sub Subscription, ch <-chan ExtrinsicStatus, statuses chan<- ExtrinsicStatus) {
+	var err error
+	var status trace.Span
+	defer func() {
+		if status != nil {
+			status.End()
+		}
+		endSpan(span, err)
+	}()
+	defer sub.Unsubscribe()
+
+	for {
+		select {
+		case s := <-ch:
+			if status != nil {
+				status.End()
+			}
+			_, status = a.startSpan(ctx, "extrinsic.status "+s.String())
+			span.AddEvent("status", trace.WithAttributes(attribute.String("substrate.status", s.String())))
+
+			select {
+			case statuses <- s:
+			case <-ctx.Done():
+				err = ctx.Err()
+				return
+			}
+
+			if s.IsFinal() {
+				if s.IsDropped || s.IsInvalid {
+					err = fmt.Errorf("extrinsic %s", s.String())
+				}
+				return
+			}
+		case err = <-sub.Err():
+			return
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+	}
+}
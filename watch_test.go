@@ -0,0 +1,57 @@
+package substrate
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestExtrinsicStatus_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json   string
+		status ExtrinsicStatus
+		final  bool
+	}{
+		{`"future"`, ExtrinsicStatus{IsFuture: true}, false},
+		{`"ready"`, ExtrinsicStatus{IsReady: true}, false},
+		{`{"broadcast":["peer"]}`, ExtrinsicStatus{IsBroadcast: true, AsBroadcast: []string{"peer"}}, false},
+		{`{"finalized":"0x0102"}`, ExtrinsicStatus{IsFinalized: true, AsFinalized: Hash{1, 2}}, true},
+		{`{"usurped":"0x03"}`, ExtrinsicStatus{IsUsurped: true, AsUsurped: Hash{3}}, true},
+		{`"dropped"`, ExtrinsicStatus{IsDropped: true}, true},
+		{`"invalid"`, ExtrinsicStatus{IsInvalid: true}, true},
+	}
+	for _, test := range tests {
+		var s ExtrinsicStatus
+		assert.NoError(t, json.Unmarshal([]byte(test.json), &s), test.json)
+		assert.Equal(t, test.status, s)
+		assert.Equal(t, test.final, s.IsFinal(), test.json)
+	}
+
+	var s ExtrinsicStatus
+	assert.Error(t, json.Unmarshal([]byte(`"retracted"`), &s))
+	assert.Error(t, json.Unmarshal([]byte(`{"retracted":"0x01"}`), &s))
+}
+
+func TestAuthor_SubmitAndWatchExtrinsic_Node(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := Connect(n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	a := newTestAuthor(t, c)
+	a1, _ := testAnchorCall()
+	statuses := make(chan ExtrinsicStatus, 2)
+	sub, err := a.SubmitAndWatchExtrinsic(context.Background(), "kerplunk.commit", a1, statuses)
+	assert.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	assert.True(t, (<-statuses).IsReady)
+	block := n.ProduceBlock()
+	s := <-statuses
+	assert.True(t, s.IsFinalized)
+	assert.Equal(t, Hash(block), s.AsFinalized)
+}
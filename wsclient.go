@@ -0,0 +1,563 @@
+package substrate
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// wsWriteTimeout bounds the write of a request to the websocket
+	wsWriteTimeout = 10 * time.Second
+	// maxSubscriptionBuffer is the number of notifications buffered for a slow subscriber before the subscription
+	// is ended with errSubscriptionOverflow
+	maxSubscriptionBuffer = 20000
+	// unsubscribeTimeout bounds the unsubscribe request sent when a subscription is unsubscribed
+	unsubscribeTimeout = 5 * time.Second
+)
+
+var errSubscriptionOverflow = errors.New("subscription buffer overflow")
+
+// jsonrpcMessage is a JSON-RPC 2.0 request, response or notification
+type jsonrpcMessage struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonError      `json:"error,omitempty"`
+}
+
+// jsonError is the error of a JSON-RPC response, substrate sends the details of an error in its data, eg: the reason
+// of an invalid transaction
+type jsonError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *jsonError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("json-rpc error %d", e.Code)
+	}
+	return e.Message
+}
+
+func (e *jsonError) ErrorCode() int {
+	return e.Code
+}
+
+// ErrorData returns the data of the error, nil if there is none
+func (e *jsonError) ErrorData() interface{} {
+	if len(e.Data) == 0 {
+		return nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(e.Data, &v); err != nil {
+		return string(e.Data)
+	}
+	return v
+}
+
+// DataError is an RPC error with data, eg: the reason of an invalid transaction
+type DataError interface {
+	Error() string
+	ErrorData() interface{}
+}
+
+// subscriptionNotification are the params of a notification
+type subscriptionNotification struct {
+	Subscription json.RawMessage `json:"subscription"`
+	Result       json.RawMessage `json:"result"`
+}
+
+// pendingRequest waits for the response of a request, the subscription is registered by the reader with the ID of
+// the response so that no notification is missed
+type pendingRequest struct {
+	resp chan *jsonrpcMessage
+	sub  *wsSubscription
+}
+
+// wsClient is a JSON-RPC 2.0 client over a websocket. The notifications are routed by their method and subscription
+// ID, following the substrate subscriptions, eg: chain_subscribeNewHead sends chain_newHead notifications.
+type wsClient struct {
+	conn    *websocket.Conn
+	writeMu sync.Mutex
+
+	mu      sync.Mutex
+	nextID  uint64
+	pending map[uint64]*pendingRequest
+	subs    map[string]*wsSubscription
+	// err is set once the connection failed or was closed
+	err error
+}
+
+// dialWebsocket connects to the websocket endpoint of a node
+func dialWebsocket(ctx context.Context, endpoint string) (*wsClient, error) {
+	config, err := websocket.NewConfig(endpoint, "http://localhost")
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		port := "80"
+		if u.Scheme == "wss" {
+			port = "443"
+		}
+		host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	var d net.Dialer
+	nc, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme == "wss" {
+		tc := tls.Client(nc, &tls.Config{ServerName: u.Hostname()})
+		if err = tc.Handshake(); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		nc = tc
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+	}
+	conn, err := websocket.NewClient(config, nc)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	nc.SetDeadline(time.Time{})
+
+	c := &wsClient{conn: conn, pending: make(map[uint64]*pendingRequest), subs: make(map[string]*wsSubscription)}
+	go c.read()
+	return c, nil
+}
+
+// read dispatches the responses and the notifications until the connection fails
+func (c *wsClient) read() {
+	for {
+		var data []byte
+		err := websocket.Message.Receive(c.conn, &data)
+		if err != nil {
+			c.fail(err)
+			return
+		}
+
+		var msgs []*jsonrpcMessage
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] == '[' {
+			err = json.Unmarshal(data, &msgs)
+		} else {
+			var msg jsonrpcMessage
+			err = json.Unmarshal(data, &msg)
+			msgs = append(msgs, &msg)
+		}
+		if err != nil {
+			// not a JSON-RPC message
+			continue
+		}
+
+		for _, msg := range msgs {
+			c.dispatch(msg)
+		}
+	}
+}
+
+func (c *wsClient) dispatch(msg *jsonrpcMessage) {
+	if msg.Method != "" && len(msg.ID) == 0 {
+		var n subscriptionNotification
+		if err := json.Unmarshal(msg.Params, &n); err != nil {
+			return
+		}
+
+		c.mu.Lock()
+		sub := c.subs[subscriptionKey(msg.Method, n.Subscription)]
+		c.mu.Unlock()
+		if sub != nil {
+			sub.deliver(n.Result)
+		}
+		return
+	}
+
+	var id uint64
+	if err := json.Unmarshal(msg.ID, &id); err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[id]
+	if !ok {
+		return
+	}
+	delete(c.pending, id)
+
+	if p.sub != nil && msg.Error == nil {
+		p.sub.id = msg.Result
+		c.subs[subscriptionKey(p.sub.methods.Notification, msg.Result)] = p.sub
+	}
+	p.resp <- msg
+}
+
+// fail ends the pending requests and the subscriptions with the error of the connection
+func (c *wsClient) fail(err error) {
+	c.mu.Lock()
+	if c.err == nil {
+		c.err = err
+	}
+	pending := c.pending
+	subs := c.subs
+	c.pending = make(map[uint64]*pendingRequest)
+	c.subs = make(map[string]*wsSubscription)
+	c.mu.Unlock()
+
+	for _, p := range pending {
+		close(p.resp)
+	}
+	for _, s := range subs {
+		s.end(err)
+	}
+}
+
+// connErr returns the error that ended the connection
+func (c *wsClient) connErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+func newRequest(id uint64, method string, args []interface{}) (*jsonrpcMessage, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+
+	params, err := json.Marshal(args)
+	if err != nil {
+		return nil, err
+	}
+
+	return &jsonrpcMessage{Version: "2.0", ID: json.RawMessage(fmt.Sprint(id)), Method: method, Params: params}, nil
+}
+
+// register adds a pending request for each message
+func (c *wsClient) register(n int, sub *wsSubscription) ([]uint64, []*pendingRequest, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return nil, nil, c.err
+	}
+
+	ids := make([]uint64, n)
+	ps := make([]*pendingRequest, n)
+	for i := range ids {
+		c.nextID++
+		ids[i] = c.nextID
+		ps[i] = &pendingRequest{resp: make(chan *jsonrpcMessage, 1), sub: sub}
+		c.pending[ids[i]] = ps[i]
+	}
+	return ids, ps, nil
+}
+
+func (c *wsClient) unregister(ids []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, id := range ids {
+		delete(c.pending, id)
+	}
+}
+
+func (c *wsClient) write(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	return websocket.Message.Send(c.conn, string(b))
+}
+
+// wait returns the response of the pending request
+func (c *wsClient) wait(ctx context.Context, p *pendingRequest) (*jsonrpcMessage, error) {
+	select {
+	case msg, ok := <-p.resp:
+		if !ok {
+			return nil, c.connErr()
+		}
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// roundTrip sends the request and waits for its response, the subscription is registered with the ID of a successful
+// response
+func (c *wsClient) roundTrip(ctx context.Context, sub *wsSubscription, method string, args ...interface{}) (*jsonrpcMessage, error) {
+	ids, ps, err := c.register(1, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := newRequest(ids[0], method, args)
+	if err == nil {
+		err = c.write(req)
+	}
+	if err != nil {
+		c.unregister(ids)
+		return nil, err
+	}
+
+	msg, err := c.wait(ctx, ps[0])
+	if err != nil && sub != nil {
+		// the node may accept the subscription after the context is done
+		go sub.cancel(ps[0])
+		return nil, err
+	}
+	if err != nil {
+		c.unregister(ids)
+		return nil, err
+	}
+	return msg, nil
+}
+
+func (c *wsClient) Call(result interface{}, method string, args ...interface{}) error {
+	return c.CallContext(context.Background(), result, method, args...)
+}
+
+func (c *wsClient) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	msg, err := c.roundTrip(ctx, nil, method, args...)
+	if err != nil {
+		return err
+	}
+
+	if msg.Error != nil {
+		return msg.Error
+	}
+	if result == nil || len(msg.Result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(msg.Result, result)
+}
+
+func (c *wsClient) BatchCall(b []rpc.BatchElem) error {
+	return c.BatchCallContext(context.Background(), b)
+}
+
+func (c *wsClient) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	if len(b) == 0 {
+		return nil
+	}
+
+	ids, ps, err := c.register(len(b), nil)
+	if err != nil {
+		return err
+	}
+
+	reqs := make([]*jsonrpcMessage, len(b))
+	for i, e := range b {
+		reqs[i], err = newRequest(ids[i], e.Method, e.Args)
+		if err != nil {
+			c.unregister(ids)
+			return err
+		}
+	}
+
+	err = c.write(reqs)
+	if err != nil {
+		c.unregister(ids)
+		return err
+	}
+
+	for i, p := range ps {
+		msg, err := c.wait(ctx, p)
+		if err != nil {
+			c.unregister(ids)
+			return err
+		}
+
+		switch {
+		case msg.Error != nil:
+			b[i].Error = msg.Error
+		case b[i].Result != nil && len(msg.Result) > 0:
+			b[i].Error = json.Unmarshal(msg.Result, b[i].Result)
+		}
+	}
+	return nil
+}
+
+// Subscribe sends the subscribe request and forwards the notifications of the subscription to the channel until the
+// context is done or the subscription is unsubscribed
+func (c *wsClient) Subscribe(ctx context.Context, methods SubscriptionMethods, channel interface{}, args ...interface{}) (Subscription, error) {
+	ch := reflect.ValueOf(channel)
+	if ch.Kind() != reflect.Chan || ch.Type().ChanDir()&reflect.SendDir == 0 {
+		return nil, fmt.Errorf("invalid channel %T", channel)
+	}
+
+	sub := &wsSubscription{
+		client:  c,
+		methods: methods,
+		channel: ch,
+		in:      make(chan json.RawMessage, maxSubscriptionBuffer),
+		err:     make(chan error, 1),
+		quit:    make(chan struct{}),
+	}
+	msg, err := c.roundTrip(ctx, sub, methods.Subscribe, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if msg.Error != nil {
+		return nil, msg.Error
+	}
+
+	go sub.forward()
+	return unsubscribeOnDone(ctx, sub), nil
+}
+
+func (c *wsClient) Close() {
+	c.fail(rpc.ErrClientQuit)
+	c.conn.Close()
+}
+
+// subscriptionKey identifies a subscription by its notification method and its ID, a string or a number
+func subscriptionKey(notification string, id json.RawMessage) string {
+	var s string
+	if err := json.Unmarshal(id, &s); err == nil {
+		return notification + "/" + s
+	}
+	return notification + "/" + string(bytes.TrimSpace(id))
+}
+
+// wsSubscription is a subscription of wsClient, the notifications are buffered and decoded in to the channel of the
+// subscriber
+type wsSubscription struct {
+	client  *wsClient
+	methods SubscriptionMethods
+	// id is set by the reader once the node accepted the subscription
+	id      json.RawMessage
+	channel reflect.Value
+
+	in  chan json.RawMessage
+	err chan error
+
+	quit    chan struct{}
+	once    sync.Once
+	quitErr error
+}
+
+// deliver buffers the notification, the subscription ends if the subscriber doesn't keep up
+func (s *wsSubscription) deliver(result json.RawMessage) {
+	select {
+	case s.in <- result:
+	default:
+		s.remove()
+		s.end(errSubscriptionOverflow)
+	}
+}
+
+// forward decodes the notifications in to the channel until the subscription ends
+func (s *wsSubscription) forward() {
+	defer func() {
+		if s.quitErr != nil {
+			s.err <- s.quitErr
+		}
+		close(s.err)
+	}()
+
+	quit := reflect.ValueOf(s.quit)
+	for {
+		select {
+		case result := <-s.in:
+			v := reflect.New(s.channel.Type().Elem())
+			if err := json.Unmarshal(result, v.Interface()); err != nil {
+				s.remove()
+				s.end(err)
+				return
+			}
+
+			cases := []reflect.SelectCase{
+				{Dir: reflect.SelectSend, Chan: s.channel, Send: v.Elem()},
+				{Dir: reflect.SelectRecv, Chan: quit},
+			}
+			if chosen, _, _ := reflect.Select(cases); chosen == 1 {
+				return
+			}
+		case <-s.quit:
+			return
+		}
+	}
+}
+
+// end ends the subscription with the error, nil once unsubscribed
+func (s *wsSubscription) end(err error) {
+	s.once.Do(func() {
+		s.quitErr = err
+		close(s.quit)
+	})
+}
+
+// remove stops routing the notifications to the subscription and returns its ID if it was routed
+func (s *wsSubscription) remove() json.RawMessage {
+	s.client.mu.Lock()
+	defer s.client.mu.Unlock()
+	if s.id == nil {
+		return nil
+	}
+
+	key := subscriptionKey(s.methods.Notification, s.id)
+	if s.client.subs[key] != s {
+		return nil
+	}
+	delete(s.client.subs, key)
+	return s.id
+}
+
+// cancel unsubscribes the subscription once the node accepts it, the subscribe request was abandoned
+func (s *wsSubscription) cancel(p *pendingRequest) {
+	msg, ok := <-p.resp
+	if ok && msg.Error == nil {
+		s.Unsubscribe()
+	}
+}
+
+func (s *wsSubscription) Err() <-chan error {
+	return s.err
+}
+
+// Unsubscribe ends the subscription and sends the unsubscribe request, the error channel is closed
+func (s *wsSubscription) Unsubscribe() {
+	id := s.remove()
+	s.end(nil)
+	if id == nil || s.methods.Unsubscribe == "" {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unsubscribeTimeout)
+		defer cancel()
+		s.client.CallContext(ctx, nil, s.methods.Unsubscribe, id)
+	}()
+}
@@ -0,0 +1,89 @@
+package substrate
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/vimukthi-git/go-substrate/substratetest"
+)
+
+func TestWsClient_Batch(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := dialWebsocket(context.Background(), n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	var hash, name string
+	b := []rpc.BatchElem{
+		{Method: "chain_getBlockHash", Args: []interface{}{0}, Result: &hash},
+		{Method: "system_name", Result: &name},
+		{Method: "chain_unknown", Result: &name},
+	}
+	assert.NoError(t, c.BatchCall(b))
+	assert.NoError(t, b[0].Error)
+	assert.Equal(t, hexutil.Encode(n.BlockHash(0)), hash)
+	assert.Equal(t, "substratetest", name)
+	assert.Equal(t, -32601, b[2].Error.(rpc.Error).ErrorCode())
+}
+
+func TestWsClient_Subscribe(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := dialWebsocket(context.Background(), n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	heads := make(chan Header, 1)
+	finalized := make(chan Header, 1)
+	sub, err := c.Subscribe(context.Background(), NewHeadSubscription, heads)
+	assert.NoError(t, err)
+	fsub, err := c.Subscribe(context.Background(), FinalizedHeadSubscription, finalized)
+	assert.NoError(t, err)
+
+	// the notifications are routed by method and subscription ID
+	n.ProduceBlock()
+	assert.Equal(t, uint64(1), (<-heads).Number)
+	assert.Equal(t, uint64(1), (<-finalized).Number)
+	sub.Unsubscribe()
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+
+	// the subscriptions end with the connection
+	n.Close()
+	assert.Error(t, <-fsub.Err())
+	assert.Error(t, c.Call(new(string), "system_name"))
+}
+
+func TestWsClient_Subscribe_Context(t *testing.T) {
+	n := substratetest.NewNode()
+	defer n.Close()
+	c, err := dialWebsocket(context.Background(), n.URL)
+	assert.NoError(t, err)
+	defer c.Close()
+
+	// the subscription is unsubscribed once its context is done
+	ctx, cancel := context.WithCancel(context.Background())
+	sub, err := c.Subscribe(ctx, NewHeadSubscription, make(chan Header))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n.Subscriptions())
+	cancel()
+	_, ok := <-sub.Err()
+	assert.False(t, ok)
+	assert.Eventually(t, func() bool {
+		return n.Subscriptions() == 0
+	}, time.Second, time.Millisecond)
+
+	// the subscription accepted by the node after the context is done is unsubscribed
+	_, err = c.Subscribe(ctx, NewHeadSubscription, make(chan Header))
+	assert.Equal(t, context.Canceled, err)
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return n.Subscriptions() == 0 && len(c.subs) == 0 && len(c.pending) == 0
+	}, time.Second, time.Millisecond)
+}